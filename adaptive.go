@@ -0,0 +1,208 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand"
+)
+
+// Render a world using adaptive anti-aliasing. Rays are traced through the
+// corners of each pixel, which are shared with the neighboring pixels, so flat
+// areas of the image cost roughly one ray per pixel. Pixels whose corners
+// differ by more than the contrast threshold are recursively split into
+// quadrants until the corners agree or the maximum depth is reached.
+func renderAdaptive(camera Camera, world World, options RenderOptions) RenderResult {
+	sampler := adaptiveSampler{
+		camera:    camera,
+		world:     world,
+		options:   options,
+		threshold: options.AdaptiveThreshold,
+		maxDepth:  options.AdaptiveMaxDepth,
+		random:    makeSampleRandom(options.Seed),
+	}
+	image := MakeCanvas(camera.Width, camera.Height)
+	region := options.region(camera)
+	var stats RenderStats
+
+	top := sampler.traceCornerRow(region.Min.X, region.Max.X, region.Min.Y)
+	stats.PrimaryRays += len(top)
+
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		bottom := sampler.traceCornerRow(region.Min.X, region.Max.X, y+1)
+		stats.PrimaryRays += len(bottom)
+
+		for x := region.Min.X; x < region.Max.X; x++ {
+			i := x - region.Min.X
+			corners := [4]adaptiveSample{top[i], top[i+1], bottom[i], bottom[i+1]}
+
+			sampler.rays = 0
+			sample := sampler.sampleArea(float64(x), float64(y), 1, corners, 0)
+			if sampler.rays > 0 {
+				stats.SubdividedPixels++
+				stats.AdaptiveRays += sampler.rays
+			}
+
+			image.SetPixel(x, y, sample.color)
+			image.SetAlpha(x, y, sample.alpha)
+		}
+
+		top = bottom
+
+		log.Printf("Rendered row %d of %d\n", y+1-region.Min.Y, region.Dy())
+	}
+
+	result := RenderResult{Canvas: image, Stats: stats}
+	if options.Passes != 0 {
+		result.Passes = renderCenterPasses(camera, world, options)
+	}
+
+	return result
+}
+
+// Render passes by tracing a single ray through the center of each pixel. The
+// corner samples of adaptive renders don't belong to a single pixel, so passes
+// are traced separately. The rays aren't counted in the render's statistics,
+// which describe the work done by adaptive sampling.
+func renderCenterPasses(camera Camera, world World, options RenderOptions) map[RenderPass]Canvas {
+	random := makeSampleRandom(options.Seed)
+	passes := makePassBuffers(camera.Width, camera.Height, options.Passes, MakeBoxFilter())
+	center := SampleOffset{0.5, 0.5}
+	region := options.region(camera)
+
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		for x := region.Min.X; x < region.Max.X; x++ {
+			random.Seed(sampleSeed(options.Seed, float64(x), float64(y), 0))
+			ray := camera.MakeRayForSample(x, y, makeCameraSample(camera, center, random))
+			computation, hit := world.primaryHit(ray)
+
+			passes.AddSample(float64(x)+center.X, float64(y)+center.Y, computation, hit)
+		}
+	}
+
+	return passes.Canvases()
+}
+
+// State used to adaptively sample areas of the camera's view.
+type adaptiveSampler struct {
+	camera    Camera
+	world     World
+	options   RenderOptions
+	threshold float64
+	maxDepth  int
+	random    *rand.Rand
+
+	// The number of refinement rays traced since the counter was last reset.
+	rays int
+}
+
+// The premultiplied color and alpha value seen through a point on the canvas.
+type adaptiveSample struct {
+	color Color
+	alpha float64
+}
+
+// Get the sample seen through a point on the canvas, given in pixel units.
+// Corners shared by neighboring pixels are traced once for each row of pixels
+// they border, so the random numbers are derived from the point to give the
+// same sample every time.
+func (s *adaptiveSampler) trace(x, y float64) adaptiveSample {
+	s.random.Seed(sampleSeed(s.options.Seed, x, y, 0))
+	cameraSample := makeCameraSample(s.camera, SampleOffset{x, y}, s.random)
+	color, _, hit := traceCameraRay(s.world, s.camera.MakeRayForSample(0, 0, cameraSample), s.options, s.random)
+	color, alpha := applyBackground(color, hit, s.options)
+
+	return adaptiveSample{color: color, alpha: alpha}
+}
+
+// Trace the samples seen through the top corners of the pixels in a row from
+// minX up to, but not including, maxX. The row one past the last row of pixels
+// gives the bottom corners of the image.
+func (s *adaptiveSampler) traceCornerRow(minX, maxX, y int) []adaptiveSample {
+	corners := make([]adaptiveSample, maxX-minX+1)
+	for i := range corners {
+		corners[i] = s.trace(float64(minX+i), float64(y))
+	}
+
+	return corners
+}
+
+// Compute the sample of a square area of the canvas given the samples at its
+// top left, top right, bottom left, and bottom right corners. If the corners
+// differ too much, the area is split into four quadrants which are sampled
+// individually.
+func (s *adaptiveSampler) sampleArea(x, y, size float64, corners [4]adaptiveSample, depth int) adaptiveSample {
+	if depth >= s.maxDepth || sampleContrast(corners[:]) <= s.threshold {
+		return averageSamples(corners[:])
+	}
+
+	half := size / 2
+	top := s.trace(x+half, y)
+	left := s.trace(x, y+half)
+	center := s.trace(x+half, y+half)
+	right := s.trace(x+size, y+half)
+	bottom := s.trace(x+half, y+size)
+	s.rays += 5
+
+	topLeft, topRight, bottomLeft, bottomRight := corners[0], corners[1], corners[2], corners[3]
+
+	return averageSamples([]adaptiveSample{
+		s.sampleArea(x, y, half, [4]adaptiveSample{topLeft, top, left, center}, depth+1),
+		s.sampleArea(x+half, y, half, [4]adaptiveSample{top, topRight, center, right}, depth+1),
+		s.sampleArea(x, y+half, half, [4]adaptiveSample{left, center, bottomLeft, bottom}, depth+1),
+		s.sampleArea(x+half, y+half, half, [4]adaptiveSample{center, right, bottom, bottomRight}, depth+1),
+	})
+}
+
+// Get the average of a set of samples.
+func averageSamples(samples []adaptiveSample) adaptiveSample {
+	colors := make([]Color, len(samples))
+	alpha := 0.0
+	for i, sample := range samples {
+		colors[i] = sample.color
+		alpha += sample.alpha
+	}
+
+	return adaptiveSample{color: averageColors(colors), alpha: alpha / float64(len(samples))}
+}
+
+// Measure the contrast between a set of samples as the largest difference
+// between any two of the samples in a single color channel or in alpha.
+func sampleContrast(samples []adaptiveSample) float64 {
+	colors := make([]Color, len(samples))
+	low, high := math.Inf(1), math.Inf(-1)
+	for i, sample := range samples {
+		colors[i] = sample.color
+		low = math.Min(low, sample.alpha)
+		high = math.Max(high, sample.alpha)
+	}
+
+	return math.Max(colorContrast(colors), high-low)
+}
+
+// Get the average of a set of colors.
+func averageColors(colors []Color) Color {
+	sum := MakeColor(0, 0, 0)
+	for _, color := range colors {
+		sum = sum.Add(color)
+	}
+
+	return sum.Multiply(1 / float64(len(colors)))
+}
+
+// Measure the contrast between a set of colors as the largest difference
+// between any two of the colors in a single channel.
+func colorContrast(colors []Color) float64 {
+	contrast := 0.0
+	channels := []func(Color) float64{Color.Red, Color.Green, Color.Blue}
+	for _, channel := range channels {
+		low, high := math.Inf(1), math.Inf(-1)
+		for _, color := range colors {
+			low = math.Min(low, channel(color))
+			high = math.Max(high, channel(color))
+		}
+
+		contrast = math.Max(contrast, high-low)
+	}
+
+	return contrast
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func adaptiveTestCamera() Camera {
+	camera := MakeCamera(11, 11, math.Pi/2)
+	camera.Transform = ViewTransform(
+		MakePoint(0, 0, -5),
+		MakePoint(0, 0, 0),
+		MakeVector(0, 1, 0),
+	)
+
+	return camera
+}
+
+func TestRenderWithOptions_Adaptive(t *testing.T) {
+	testCases := []struct {
+		name           string
+		world          World
+		threshold      float64
+		maxDepth       int
+		wantSubdivided bool
+	}{
+		{"empty world is flat", MakeWorld(), 0.1, 3, false},
+		{"sphere edges are subdivided", MakeDefaultWorld(), 0.1, 3, true},
+		{"high threshold", MakeDefaultWorld(), 10, 3, false},
+		{"zero depth", MakeDefaultWorld(), 0.1, 0, false},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			camera := adaptiveTestCamera()
+			options := MakeRenderOptions()
+			options.Adaptive = true
+			options.AdaptiveThreshold = tt.threshold
+			options.AdaptiveMaxDepth = tt.maxDepth
+
+			stats := RenderWithOptions(camera, tt.world, options).Stats
+
+			// Rays traced for render passes don't count towards adaptive
+			// sampling.
+			options.Passes = PassDepth | PassNormal
+			if got := RenderWithOptions(camera, tt.world, options).Stats; got != stats {
+				t.Errorf("Expected render passes to leave the statistics at %+v, got %+v", stats, got)
+			}
+
+			if want, got := 12*12, stats.PrimaryRays; got != want {
+				t.Errorf("Expected %d primary rays, got %d", want, got)
+			}
+
+			if got := stats.SubdividedPixels > 0; got != tt.wantSubdivided {
+				t.Errorf("Expected subdivided = %v, got %d subdivided pixels", tt.wantSubdivided, stats.SubdividedPixels)
+			}
+
+			if !tt.wantSubdivided && stats.AdaptiveRays != 0 {
+				t.Errorf("Expected no adaptive rays, got %d", stats.AdaptiveRays)
+			}
+
+			if tt.wantSubdivided && stats.AdaptiveRays < 5*stats.SubdividedPixels {
+				t.Errorf("Expected at least 5 rays per subdivided pixel, got %d for %d pixels", stats.AdaptiveRays, stats.SubdividedPixels)
+			}
+		})
+	}
+}
+
+// Only the silhouette of the sphere has high contrast, so the flat interior
+// and background should not be refined.
+func TestRenderWithOptions_AdaptiveSkipsFlatAreas(t *testing.T) {
+	camera := adaptiveTestCamera()
+	options := MakeRenderOptions()
+	options.Adaptive = true
+	options.AdaptiveThreshold = 0.2
+
+	stats := RenderWithOptions(camera, MakeDefaultWorld(), options).Stats
+
+	if total := camera.Width * camera.Height; stats.SubdividedPixels >= total {
+		t.Errorf("Expected fewer than %d subdivided pixels, got %d", total, stats.SubdividedPixels)
+	}
+}
+
+func TestColorContrast(t *testing.T) {
+	testCases := []struct {
+		name   string
+		colors []Color
+		want   float64
+	}{
+		{
+			"identical",
+			[]Color{MakeColor(0.5, 0.5, 0.5), MakeColor(0.5, 0.5, 0.5)},
+			0,
+		},
+		{
+			"largest channel difference",
+			[]Color{MakeColor(0, 0.5, 1), MakeColor(0.25, 0.5, 0.2), MakeColor(0.1, 0.4, 0.9)},
+			0.8,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := colorContrast(tt.colors); !Float64Equal(tt.want, got) {
+				t.Errorf("Expected contrast %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestAverageColors(t *testing.T) {
+	colors := []Color{MakeColor(1, 0, 0), MakeColor(0, 1, 0), MakeColor(0, 0, 1), MakeColor(1, 1, 1)}
+	want := MakeColor(0.5, 0.5, 0.5)
+
+	if got := averageColors(colors); !want.Equals(got) {
+		t.Errorf("Expected average %v, got %v", want, got)
+	}
+}
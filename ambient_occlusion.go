@@ -0,0 +1,73 @@
+package main
+
+import "math"
+
+// Ambient occlusion estimates how much of the light arriving from all around a
+// point is blocked by nearby surfaces. Points in creases and corners are
+// mostly enclosed, so they receive less ambient light than exposed points.
+type AmbientOcclusion struct {
+	// The number of rays cast from each point to estimate its occlusion. Zero
+	// disables ambient occlusion.
+	Samples int
+	// Surfaces further than this distance from a point don't occlude it. A
+	// distance of zero considers surfaces at any distance.
+	MaxDistance float64
+}
+
+// Create ambient occlusion settings that cast sixteen rays up to two units
+// away.
+func MakeAmbientOcclusion() AmbientOcclusion {
+	return AmbientOcclusion{Samples: 16, MaxDistance: 2}
+}
+
+// Estimate the fraction of ambient light that reaches the point of a hit, from
+// 0 for a completely enclosed point to 1 for a completely exposed one. Rays
+// are cast in directions around the normal in proportion to their cosine with
+// the normal, since light arriving at a grazing angle contributes less.
+func (o AmbientOcclusion) Visibility(world World, computation IntersectionComputation, sampler Sampler) float64 {
+	if o.Samples < 1 {
+		return 1
+	}
+
+	maxDistance := o.MaxDistance
+	if maxDistance <= 0 {
+		maxDistance = math.Inf(1)
+	}
+
+	unoccluded := 0
+	for i := 0; i < o.Samples; i++ {
+		direction := sampleCosineHemisphere(computation.NormalVector, sampler.Float64(), sampler.Float64())
+		ray := MakeRayAtTime(computation.OverPoint, direction, computation.Time)
+
+		if hit, ok := world.intersect(ray).Hit(); !ok || hit.T >= maxDistance {
+			unoccluded++
+		}
+	}
+
+	return float64(unoccluded) / float64(o.Samples)
+}
+
+// The ambient occlusion integrator produces the grayscale images used for clay
+// renders. Each hit is shaded by how exposed it is to ambient light, ignoring
+// the world's light source and the materials of objects.
+type AmbientOcclusionIntegrator struct {
+	Occlusion AmbientOcclusion
+}
+
+// Compute the color seen along a ray.
+func (i AmbientOcclusionIntegrator) Radiance(ray Ray, world World, depth int, sampler Sampler) Color {
+	computation, hit := world.primaryHit(ray)
+
+	return i.radianceForHit(ray, world, computation, hit, sampler)
+}
+
+// Compute the color seen along a ray from the camera with a known hit.
+func (i AmbientOcclusionIntegrator) radianceForHit(ray Ray, world World, computation IntersectionComputation, hit bool, sampler Sampler) Color {
+	if !hit {
+		return world.environmentColor(ray)
+	}
+
+	visibility := i.Occlusion.Visibility(world, computation, sampler)
+
+	return MakeColor(visibility, visibility, visibility)
+}
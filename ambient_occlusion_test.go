@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAmbientOcclusion_Visibility(t *testing.T) {
+	// A floor with a ceiling one unit above it.
+	floor := MakeSphereTransformed(MakeScale(10, 0.01, 10))
+	ceiling := MakeSphereTransformed(MakeTranslation(0, 1, 0).Multiply(MakeScale(10, 0.01, 10)))
+
+	ray := MakeRay(MakePoint(0, 0.5, 0), MakeVector(0, -1, 0))
+
+	testCases := []struct {
+		name      string
+		objects   []Object
+		occlusion AmbientOcclusion
+		wantLow   float64
+		wantHigh  float64
+	}{
+		{"exposed", []Object{floor}, AmbientOcclusion{Samples: 32, MaxDistance: 2}, 1, 1},
+		{"enclosed", []Object{floor, ceiling}, AmbientOcclusion{Samples: 32, MaxDistance: 0}, 0, 0},
+		{"partially enclosed", []Object{floor, ceiling}, AmbientOcclusion{Samples: 256, MaxDistance: 2}, 0.2, 0.8},
+		{"beyond max distance", []Object{floor, ceiling}, AmbientOcclusion{Samples: 32, MaxDistance: 0.9}, 1, 1},
+		{"disabled", []Object{floor, ceiling}, AmbientOcclusion{}, 1, 1},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			world := MakeWorld()
+			world.Objects = tt.objects
+			computation, _ := world.primaryHit(ray)
+
+			got := tt.occlusion.Visibility(world, computation, rand.New(rand.NewSource(0)))
+			if got < tt.wantLow-floatEpsilon || got > tt.wantHigh+floatEpsilon {
+				t.Errorf("Expected visibility in [%v, %v], got %v", tt.wantLow, tt.wantHigh, got)
+			}
+		})
+	}
+}
+
+func TestAmbientOcclusionIntegrator_Radiance(t *testing.T) {
+	world := MakeDefaultWorld()
+	integrator := AmbientOcclusionIntegrator{Occlusion: MakeAmbientOcclusion()}
+
+	// The front of the default world's outer sphere is fully exposed.
+	ray := MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1))
+	if want, got := MakeColor(1, 1, 1), integrator.Radiance(ray, world, 0, rand.New(rand.NewSource(0))); !want.Equals(got) {
+		t.Errorf("Expected color %v, got %v", want, got)
+	}
+}
+
+func TestWhittedIntegrator_Radiance_AmbientOcclusion(t *testing.T) {
+	floor := MakeSphereTransformed(MakeScale(10, 0.01, 10))
+	ceiling := MakeSphereTransformed(MakeTranslation(0, 1, 0).Multiply(MakeScale(10, 0.01, 10)))
+
+	// The light is below the floor, so the top of the floor is only lit by
+	// ambient light, which the ceiling completely blocks.
+	world := MakeWorld()
+	world.Light = MakePointLight(MakePoint(0, -5, 0), MakeColor(1, 1, 1))
+	world.Objects = []Object{floor, ceiling}
+
+	ray := MakeRay(MakePoint(0, 0.5, 0), MakeVector(0, -1, 0))
+	integrator := WhittedIntegrator{Occlusion: AmbientOcclusion{Samples: 8}}
+
+	if want, got := MakeColor(0, 0, 0), integrator.Radiance(ray, world, 0, rand.New(rand.NewSource(0))); !want.Equals(got) {
+		t.Errorf("Expected occluded color %v, got %v", want, got)
+	}
+
+	if want, got := MakeColor(0.1, 0.1, 0.1), (WhittedIntegrator{}).Radiance(ray, world, 0, nil); !want.Equals(got) {
+		t.Errorf("Expected color without occlusion to be %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,409 @@
+package main
+
+import "math"
+
+// A BSDF (bidirectional scattering distribution function) describes how a
+// surface scatters light. Every method takes the surface's normal, which
+// points out of the object, and directions that point away from the surface
+// and are unit vectors. The outgoing direction is the one light leaves
+// towards, and the incoming direction is the one it arrives from. Surfaces
+// that only reflect light work the same from either side.
+//
+// Perfectly smooth surfaces, like mirrors and glass, only scatter light in
+// exact directions. They evaluate to black with a probability density of zero
+// for every pair of directions, and are only seen through Sample.
+type BSDF interface {
+	// Compute the fraction of light arriving from the incoming direction
+	// that is scattered towards the outgoing direction, per unit of solid
+	// angle. The cosine of the incoming direction with the normal is not
+	// included.
+	Evaluate(normal, outgoing, incoming Tuple) Color
+
+	// Choose a random incoming direction for light scattered towards the
+	// outgoing direction. The weight is the value of the BSDF times the
+	// cosine of the incoming direction with the normal divided by the
+	// probability density of the direction, which is the factor to scale the
+	// light arriving from that direction by. The boolean return is false if
+	// no light is scattered.
+	Sample(normal, outgoing Tuple, sampler Sampler) (Tuple, Color, bool)
+
+	// Get the probability density of Sample choosing the incoming direction.
+	PDF(normal, outgoing, incoming Tuple) float64
+}
+
+// A Lambertian BSDF scatters light evenly in every direction above the
+// surface.
+type LambertianBSDF struct {
+	// The fraction of light that is scattered.
+	Albedo Color
+}
+
+// Compute the light scattered from the incoming direction to the outgoing
+// direction.
+func (b LambertianBSDF) Evaluate(normal, outgoing, incoming Tuple) Color {
+	if !sameHemisphere(normal, outgoing, incoming) {
+		return MakeColor(0, 0, 0)
+	}
+
+	return b.Albedo.Multiply(1 / math.Pi)
+}
+
+// Choose an incoming direction in proportion to its cosine with the normal.
+func (b LambertianBSDF) Sample(normal, outgoing Tuple, sampler Sampler) (Tuple, Color, bool) {
+	// The cosine and the 1/pi of the BSDF cancel with the probability density
+	// of the direction, leaving only the albedo.
+	incoming := sampleCosineHemisphere(faceForward(normal, outgoing), sampler.Float64(), sampler.Float64())
+
+	return incoming, b.Albedo, true
+}
+
+// Get the probability density of Sample choosing the incoming direction.
+func (b LambertianBSDF) PDF(normal, outgoing, incoming Tuple) float64 {
+	return cosineHemispherePDF(normal, outgoing, incoming)
+}
+
+// An Oren-Nayar BSDF models rough diffuse surfaces, like clay or the moon,
+// whose tiny facets each scatter light like a Lambertian surface. Rough
+// surfaces look flatter than Lambertian ones because more light is scattered
+// back towards where it came from.
+type OrenNayarBSDF struct {
+	// The fraction of light scattered by each facet.
+	Albedo Color
+	// The standard deviation of the angle of the facets in radians. A
+	// roughness of 0 is the same as a Lambertian surface.
+	Roughness float64
+}
+
+// Compute the light scattered from the incoming direction to the outgoing
+// direction using the qualitative Oren-Nayar model.
+func (b OrenNayarBSDF) Evaluate(normal, outgoing, incoming Tuple) Color {
+	if !sameHemisphere(normal, outgoing, incoming) {
+		return MakeColor(0, 0, 0)
+	}
+
+	normal = faceForward(normal, outgoing)
+	sigma2 := b.Roughness * b.Roughness
+	a := 1 - sigma2/(2*(sigma2+0.33))
+	bTerm := 0.45 * sigma2 / (sigma2 + 0.09)
+
+	cosOutgoing := normal.Dot(outgoing)
+	cosIncoming := normal.Dot(incoming)
+	sinOutgoing := math.Sqrt(math.Max(0, 1-cosOutgoing*cosOutgoing))
+	sinIncoming := math.Sqrt(math.Max(0, 1-cosIncoming*cosIncoming))
+
+	// The cosine of the difference in azimuth between the directions.
+	cosAzimuth := 0.0
+	if sinOutgoing > 1e-4 && sinIncoming > 1e-4 {
+		tangentOutgoing := outgoing.Subtract(normal.Multiply(cosOutgoing))
+		tangentIncoming := incoming.Subtract(normal.Multiply(cosIncoming))
+		cosAzimuth = math.Max(0, tangentOutgoing.Dot(tangentIncoming)/(sinOutgoing*sinIncoming))
+	}
+
+	// Alpha is the larger of the angles of the directions with the normal and
+	// beta is the smaller.
+	sinAlpha, tanBeta := sinIncoming, sinOutgoing/cosOutgoing
+	if cosIncoming > cosOutgoing {
+		sinAlpha, tanBeta = sinOutgoing, sinIncoming/cosIncoming
+	}
+
+	return b.Albedo.Multiply((a + bTerm*cosAzimuth*sinAlpha*tanBeta) / math.Pi)
+}
+
+// Choose an incoming direction in proportion to its cosine with the normal.
+func (b OrenNayarBSDF) Sample(normal, outgoing Tuple, sampler Sampler) (Tuple, Color, bool) {
+	incoming := sampleCosineHemisphere(faceForward(normal, outgoing), sampler.Float64(), sampler.Float64())
+
+	return sampleWeight(b, normal, outgoing, incoming)
+}
+
+// Get the probability density of Sample choosing the incoming direction.
+func (b OrenNayarBSDF) PDF(normal, outgoing, incoming Tuple) float64 {
+	return cosineHemispherePDF(normal, outgoing, incoming)
+}
+
+// A Phong BSDF is an energy conserving version of the Phong model. Light is
+// scattered diffusely, plus a highlight around the direction the outgoing
+// direction is mirrored to. The diffuse and specular colors should add up to
+// at most 1 so that no more light is scattered than arrives.
+type PhongBSDF struct {
+	// The fraction of light scattered diffusely.
+	Diffuse Color
+	// The fraction of light scattered into the highlight.
+	Specular Color
+	// How tight the highlight is. Larger values give smaller, sharper
+	// highlights.
+	Shininess float64
+}
+
+// Compute the light scattered from the incoming direction to the outgoing
+// direction.
+func (b PhongBSDF) Evaluate(normal, outgoing, incoming Tuple) Color {
+	if !sameHemisphere(normal, outgoing, incoming) {
+		return MakeColor(0, 0, 0)
+	}
+
+	reflection := Reflect(outgoing.Negate(), faceForward(normal, outgoing))
+	lobe := math.Pow(math.Max(0, reflection.Dot(incoming)), b.Shininess)
+
+	return b.Diffuse.Multiply(1 / math.Pi).
+		Add(b.Specular.Multiply((b.Shininess + 2) / (2 * math.Pi) * lobe))
+}
+
+// Choose an incoming direction from either the diffuse lobe or the highlight.
+func (b PhongBSDF) Sample(normal, outgoing Tuple, sampler Sampler) (Tuple, Color, bool) {
+	facing := faceForward(normal, outgoing)
+	probability, ok := lobeProbability(b.Diffuse, b.Specular)
+	if !ok {
+		return facing, MakeColor(0, 0, 0), false
+	}
+
+	var incoming Tuple
+	if sampler.Float64() < probability {
+		reflection := Reflect(outgoing.Negate(), facing)
+		incoming = samplePowerCosine(reflection, b.Shininess, sampler.Float64(), sampler.Float64())
+	} else {
+		incoming = sampleCosineHemisphere(facing, sampler.Float64(), sampler.Float64())
+	}
+
+	return sampleWeight(b, normal, outgoing, incoming)
+}
+
+// Get the probability density of Sample choosing the incoming direction.
+func (b PhongBSDF) PDF(normal, outgoing, incoming Tuple) float64 {
+	probability, ok := lobeProbability(b.Diffuse, b.Specular)
+	if !ok || !sameHemisphere(normal, outgoing, incoming) {
+		return 0
+	}
+
+	reflection := Reflect(outgoing.Negate(), faceForward(normal, outgoing))
+	specular := powerCosinePDF(reflection.Dot(incoming), b.Shininess)
+
+	return probability*specular + (1-probability)*cosineHemispherePDF(normal, outgoing, incoming)
+}
+
+// A Blinn-Phong BSDF is an energy conserving version of the Blinn-Phong
+// model. It is like the Phong BSDF, but the highlight depends on how close the
+// vector halfway between the directions is to the normal, which gives
+// highlights that stretch out at grazing angles. The diffuse and specular
+// colors should add up to at most 1.
+type BlinnPhongBSDF struct {
+	// The fraction of light scattered diffusely.
+	Diffuse Color
+	// The fraction of light scattered into the highlight.
+	Specular Color
+	// How tight the highlight is. Larger values give smaller, sharper
+	// highlights.
+	Shininess float64
+}
+
+// Compute the light scattered from the incoming direction to the outgoing
+// direction.
+func (b BlinnPhongBSDF) Evaluate(normal, outgoing, incoming Tuple) Color {
+	if !sameHemisphere(normal, outgoing, incoming) {
+		return MakeColor(0, 0, 0)
+	}
+
+	halfway := outgoing.Add(incoming).Normalized()
+	lobe := math.Pow(math.Max(0, faceForward(normal, outgoing).Dot(halfway)), b.Shininess)
+
+	return b.Diffuse.Multiply(1 / math.Pi).
+		Add(b.Specular.Multiply((b.Shininess + 8) / (8 * math.Pi) * lobe))
+}
+
+// Choose an incoming direction from either the diffuse lobe or the highlight.
+func (b BlinnPhongBSDF) Sample(normal, outgoing Tuple, sampler Sampler) (Tuple, Color, bool) {
+	facing := faceForward(normal, outgoing)
+	probability, ok := lobeProbability(b.Diffuse, b.Specular)
+	if !ok {
+		return facing, MakeColor(0, 0, 0), false
+	}
+
+	var incoming Tuple
+	if sampler.Float64() < probability {
+		halfway := samplePowerCosine(facing, b.Shininess, sampler.Float64(), sampler.Float64())
+		incoming = Reflect(outgoing.Negate(), halfway)
+	} else {
+		incoming = sampleCosineHemisphere(facing, sampler.Float64(), sampler.Float64())
+	}
+
+	return sampleWeight(b, normal, outgoing, incoming)
+}
+
+// Get the probability density of Sample choosing the incoming direction.
+func (b BlinnPhongBSDF) PDF(normal, outgoing, incoming Tuple) float64 {
+	probability, ok := lobeProbability(b.Diffuse, b.Specular)
+	if !ok || !sameHemisphere(normal, outgoing, incoming) {
+		return 0
+	}
+
+	facing := faceForward(normal, outgoing)
+	halfway := outgoing.Add(incoming).Normalized()
+	// Reflecting about the halfway vector changes the density of directions
+	// by this Jacobian.
+	specular := powerCosinePDF(facing.Dot(halfway), b.Shininess) / (4 * outgoing.Dot(halfway))
+
+	return probability*specular + (1-probability)*cosineHemispherePDF(normal, outgoing, incoming)
+}
+
+// A mirror BSDF reflects light in exactly one direction.
+type MirrorBSDF struct {
+	// The fraction of light that is reflected.
+	Color Color
+}
+
+// Perfect mirrors scatter no light between any pair of directions that could
+// be chosen at random.
+func (b MirrorBSDF) Evaluate(normal, outgoing, incoming Tuple) Color {
+	return MakeColor(0, 0, 0)
+}
+
+// Get the direction the outgoing direction is mirrored to.
+func (b MirrorBSDF) Sample(normal, outgoing Tuple, sampler Sampler) (Tuple, Color, bool) {
+	return Reflect(outgoing.Negate(), faceForward(normal, outgoing)), b.Color, true
+}
+
+// Perfect mirrors have no probability density.
+func (b MirrorBSDF) PDF(normal, outgoing, incoming Tuple) float64 {
+	return 0
+}
+
+// A dielectric BSDF models smooth transparent surfaces like glass and water.
+// Light is either reflected or refracted through the surface, in proportion to
+// the Fresnel equations.
+type DielectricBSDF struct {
+	// The index of refraction of the inside of the object. The outside is
+	// assumed to be a vacuum.
+	IndexOfRefraction float64
+	// The fraction of light that is refracted through the surface.
+	Color Color
+}
+
+// Smooth dielectrics scatter no light between any pair of directions that
+// could be chosen at random.
+func (b DielectricBSDF) Evaluate(normal, outgoing, incoming Tuple) Color {
+	return MakeColor(0, 0, 0)
+}
+
+// Choose between reflecting and refracting the outgoing direction in
+// proportion to how much light each carries.
+func (b DielectricBSDF) Sample(normal, outgoing Tuple, sampler Sampler) (Tuple, Color, bool) {
+	// The ratio of the index of refraction the light leaves from to the one
+	// it enters.
+	ratio := 1 / b.IndexOfRefraction
+	if normal.Dot(outgoing) < 0 {
+		ratio = b.IndexOfRefraction
+	}
+
+	facing := faceForward(normal, outgoing)
+	cosOutgoing := facing.Dot(outgoing)
+	sin2Refracted := ratio * ratio * (1 - cosOutgoing*cosOutgoing)
+
+	// Light at a steep enough angle is totally reflected.
+	reflectance := 1.0
+	cosRefracted := 0.0
+	if sin2Refracted < 1 {
+		cosRefracted = math.Sqrt(1 - sin2Refracted)
+		reflectance = dielectricFresnel(cosOutgoing, cosRefracted, ratio)
+	}
+
+	if sampler.Float64() < reflectance {
+		return Reflect(outgoing.Negate(), facing), MakeColor(1, 1, 1), true
+	}
+
+	refracted := outgoing.Negate().Multiply(ratio).Add(facing.Multiply(ratio*cosOutgoing - cosRefracted))
+
+	return refracted.Normalized(), b.Color, true
+}
+
+// Smooth dielectrics have no probability density.
+func (b DielectricBSDF) PDF(normal, outgoing, incoming Tuple) float64 {
+	return 0
+}
+
+// Compute the fraction of unpolarized light reflected by a smooth dielectric
+// surface using the Fresnel equations, given the cosines of the angles of the
+// incident and refracted rays with the normal and the ratio of the indices of
+// refraction.
+func dielectricFresnel(cosIncident, cosRefracted, ratio float64) float64 {
+	parallel := (cosIncident - ratio*cosRefracted) / (cosIncident + ratio*cosRefracted)
+	perpendicular := (ratio*cosIncident - cosRefracted) / (ratio*cosIncident + cosRefracted)
+
+	return (parallel*parallel + perpendicular*perpendicular) / 2
+}
+
+// Flip a normal to the same side of the surface as a direction.
+func faceForward(normal, direction Tuple) Tuple {
+	if normal.Dot(direction) < 0 {
+		return normal.Negate()
+	}
+
+	return normal
+}
+
+// Determine if two directions are strictly on the same side of a surface.
+func sameHemisphere(normal, a, b Tuple) bool {
+	return normal.Dot(a)*normal.Dot(b) > 0
+}
+
+// Get the probability density of choosing the incoming direction in
+// proportion to its cosine with the normal on the outgoing direction's side.
+func cosineHemispherePDF(normal, outgoing, incoming Tuple) float64 {
+	if !sameHemisphere(normal, outgoing, incoming) {
+		return 0
+	}
+
+	return math.Abs(normal.Dot(incoming)) / math.Pi
+}
+
+// Compute the return values of Sample for an incoming direction chosen with
+// the BSDF's probability density.
+func sampleWeight(bsdf BSDF, normal, outgoing, incoming Tuple) (Tuple, Color, bool) {
+	pdf := bsdf.PDF(normal, outgoing, incoming)
+	if pdf <= 0 {
+		return incoming, MakeColor(0, 0, 0), false
+	}
+
+	cosine := math.Abs(normal.Dot(incoming))
+
+	return incoming, bsdf.Evaluate(normal, outgoing, incoming).Multiply(cosine / pdf), true
+}
+
+// Get the probability of sampling the highlight of a BSDF with diffuse and
+// specular lobes, in proportion to the average reflectance of each lobe. The
+// boolean return is false if the BSDF scatters no light.
+func lobeProbability(diffuse, specular Color) (float64, bool) {
+	average := func(color Color) float64 {
+		return (color.Red() + color.Green() + color.Blue()) / 3
+	}
+
+	total := average(diffuse) + average(specular)
+	if total <= 0 {
+		return 0, false
+	}
+
+	return average(specular) / total, true
+}
+
+// Choose a direction around an axis in proportion to its cosine with the axis
+// raised to a power.
+func samplePowerCosine(axis Tuple, exponent, u, v float64) Tuple {
+	cosTheta := math.Pow(u, 1/(exponent+1))
+	sinTheta := math.Sqrt(math.Max(0, 1-cosTheta*cosTheta))
+	phi := 2 * math.Pi * v
+
+	tangent, bitangent := makeOrthonormalBasis(axis)
+
+	return tangent.Multiply(sinTheta * math.Cos(phi)).
+		Add(bitangent.Multiply(sinTheta * math.Sin(phi))).
+		Add(axis.Multiply(cosTheta))
+}
+
+// Get the probability density of samplePowerCosine choosing a direction with
+// the given cosine with the axis.
+func powerCosinePDF(cosine, exponent float64) float64 {
+	if cosine <= 0 {
+		return 0
+	}
+
+	return (exponent + 1) / (2 * math.Pi) * math.Pow(cosine, exponent)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// BSDFs that scatter light over a range of directions, for tests that apply
+// to all of them.
+var glossyBSDFs = []struct {
+	name string
+	bsdf BSDF
+}{
+	{"lambertian", LambertianBSDF{Albedo: MakeColor(0.9, 0.5, 0.2)}},
+	{"oren-nayar", OrenNayarBSDF{Albedo: MakeColor(0.9, 0.5, 0.2), Roughness: 0.5}},
+	{"phong", PhongBSDF{Diffuse: MakeColor(0.5, 0.3, 0.1), Specular: MakeColor(0.4, 0.4, 0.4), Shininess: 20}},
+	{"blinn-phong", BlinnPhongBSDF{Diffuse: MakeColor(0.5, 0.3, 0.1), Specular: MakeColor(0.4, 0.4, 0.4), Shininess: 20}},
+	{"microfacet", MicrofacetBRDF{BaseColor: MakeColor(0.9, 0.5, 0.2), Metallic: 0.5, Roughness: 0.5}},
+}
+
+// The weights returned by Sample must agree with Evaluate and PDF, so
+// estimating the scattered light either way gives the same result.
+func TestBSDF_Sample(t *testing.T) {
+	normal := MakeVector(0, 1, 0)
+	outgoing := MakeVector(0.6, 0.8, 0)
+
+	for _, tt := range glossyBSDFs {
+		t.Run(tt.name, func(t *testing.T) {
+			random := rand.New(rand.NewSource(0))
+			samples := 100000
+			sampled := MakeColor(0, 0, 0)
+			uniform := MakeColor(0, 0, 0)
+			for i := 0; i < samples; i++ {
+				incoming, weight, ok := tt.bsdf.Sample(normal, outgoing, random)
+				if ok {
+					sampled = sampled.Add(weight)
+
+					want := tt.bsdf.Evaluate(normal, outgoing, incoming).
+						Multiply(normal.Dot(incoming) / tt.bsdf.PDF(normal, outgoing, incoming))
+					if !want.Equals(weight) {
+						t.Fatalf("Expected weight %v for direction %v, got %v", want, incoming, weight)
+					}
+				}
+
+				incoming = sampleCosineHemisphere(normal, random.Float64(), random.Float64())
+				uniform = uniform.Add(tt.bsdf.Evaluate(normal, outgoing, incoming).Multiply(math.Pi))
+			}
+			sampled = sampled.Multiply(1 / float64(samples))
+			uniform = uniform.Multiply(1 / float64(samples))
+
+			diff := sampled.Subtract(uniform)
+			for _, channel := range []float64{diff.Red(), diff.Green(), diff.Blue()} {
+				if math.Abs(channel) > 0.01 {
+					t.Fatalf("Expected sampling to give %v, got %v", uniform, sampled)
+				}
+			}
+		})
+	}
+}
+
+// No BSDF scatters more light than it receives.
+func TestBSDF_WhiteFurnace(t *testing.T) {
+	normal := MakeVector(0, 0, 1)
+
+	for _, tt := range glossyBSDFs {
+		t.Run(tt.name, func(t *testing.T) {
+			random := rand.New(rand.NewSource(0))
+			for _, cosine := range []float64{1, 0.5, 0.1} {
+				outgoing := MakeVector(math.Sqrt(1-cosine*cosine), 0, cosine)
+
+				samples := 20000
+				total := MakeColor(0, 0, 0)
+				for i := 0; i < samples; i++ {
+					if _, weight, ok := tt.bsdf.Sample(normal, outgoing, random); ok {
+						total = total.Add(weight)
+					}
+				}
+				albedo := total.Multiply(1 / float64(samples))
+
+				for _, channel := range []float64{albedo.Red(), albedo.Green(), albedo.Blue()} {
+					if channel > 1.01 {
+						t.Errorf("Expected at most all light to be scattered at cosine %v, got %v", cosine, albedo)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestBSDF_Evaluate(t *testing.T) {
+	normal := MakeVector(0, 0, 1)
+	a := MakeVector(0.3, 0.4, 0.866).Normalized()
+	b := MakeVector(-0.8, 0.1, 0.2).Normalized()
+	below := MakeVector(0.5, 0, -0.5).Normalized()
+	black := MakeColor(0, 0, 0)
+
+	for _, tt := range glossyBSDFs {
+		t.Run(tt.name, func(t *testing.T) {
+			if forward, backward := tt.bsdf.Evaluate(normal, a, b), tt.bsdf.Evaluate(normal, b, a); !forward.Equals(backward) {
+				t.Errorf("Expected the BSDF to be reciprocal, got %v and %v", forward, backward)
+			}
+
+			if got := tt.bsdf.Evaluate(normal, a, below); !got.Equals(black) {
+				t.Errorf("Expected no light to be scattered through the surface, got %v", got)
+			}
+
+			// Reflective surfaces work the same from either side.
+			want := tt.bsdf.Evaluate(normal, a, b)
+			if got := tt.bsdf.Evaluate(normal.Negate(), a, b); !want.Equals(got) {
+				t.Errorf("Expected the back of the surface to scatter %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestOrenNayarBSDF_Evaluate(t *testing.T) {
+	normal := MakeVector(0, 0, 1)
+	outgoing := MakeVector(0.6, 0, 0.8)
+	incoming := MakeVector(0.8, 0, 0.6)
+	albedo := MakeColor(0.5, 0.5, 0.5)
+
+	// Without roughness, the model is Lambertian.
+	smooth := OrenNayarBSDF{Albedo: albedo}
+	want := LambertianBSDF{Albedo: albedo}.Evaluate(normal, outgoing, incoming)
+	if got := smooth.Evaluate(normal, outgoing, incoming); !want.Equals(got) {
+		t.Errorf("Expected a smooth surface to scatter %v, got %v", want, got)
+	}
+
+	// Rough surfaces scatter more light back towards where it came from than
+	// away from it.
+	rough := OrenNayarBSDF{Albedo: albedo, Roughness: 0.5}
+	back := rough.Evaluate(normal, outgoing, incoming)
+	forward := rough.Evaluate(normal, outgoing, MakeVector(-0.8, 0, 0.6))
+	if back.Red() <= forward.Red() {
+		t.Errorf("Expected a rough surface to scatter more than %v back towards the light, got %v", forward, back)
+	}
+}
+
+func TestMirrorBSDF(t *testing.T) {
+	bsdf := MirrorBSDF{Color: MakeColor(0.9, 0.8, 0.7)}
+	normal := MakeVector(0, 1, 0)
+	outgoing := MakeVector(-1, 1, 0).Normalized()
+
+	incoming, weight, ok := bsdf.Sample(normal, outgoing, rand.New(rand.NewSource(0)))
+	if !ok {
+		t.Fatalf("Expected the mirror to reflect light")
+	}
+
+	if want := MakeVector(1, 1, 0).Normalized(); !want.Equals(incoming) {
+		t.Errorf("Expected direction %v, got %v", want, incoming)
+	}
+
+	if !bsdf.Color.Equals(weight) {
+		t.Errorf("Expected weight %v, got %v", bsdf.Color, weight)
+	}
+
+	if got := bsdf.Evaluate(normal, outgoing, incoming); !got.Equals(MakeColor(0, 0, 0)) {
+		t.Errorf("Expected a mirror to evaluate to black, got %v", got)
+	}
+
+	if got := bsdf.PDF(normal, outgoing, incoming); got != 0 {
+		t.Errorf("Expected a mirror to have no density, got %v", got)
+	}
+}
+
+func TestDielectricBSDF_Sample(t *testing.T) {
+	bsdf := DielectricBSDF{IndexOfRefraction: 1.5, Color: MakeColor(1, 1, 1)}
+	normal := MakeVector(0, 1, 0)
+	half := math.Sqrt(2) / 2
+
+	testCases := []struct {
+		name     string
+		outgoing Tuple
+		// The fraction of samples that should be reflected.
+		reflected float64
+		// The direction light is refracted from.
+		refracted Tuple
+	}{
+		{
+			"head on from outside",
+			MakeVector(0, 1, 0),
+			0.04,
+			MakeVector(0, -1, 0),
+		},
+		{
+			"angled from outside",
+			MakeVector(-half, half, 0),
+			dielectricFresnel(half, math.Sqrt(1-0.5/2.25), 1/1.5),
+			MakeVector(half/1.5, -math.Sqrt(1-0.5/2.25), 0),
+		},
+		{
+			"total internal reflection",
+			MakeVector(-half, -half, 0),
+			1,
+			Tuple{},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			random := rand.New(rand.NewSource(0))
+			samples := 20000
+			reflections := 0
+			for i := 0; i < samples; i++ {
+				incoming, weight, ok := bsdf.Sample(normal, tt.outgoing, random)
+				if !ok || !weight.Equals(MakeColor(1, 1, 1)) {
+					t.Fatalf("Expected clear glass to scatter all light, got %v", weight)
+				}
+
+				if incoming.Dot(normal)*tt.outgoing.Dot(normal) > 0 {
+					reflections++
+					if want := Reflect(tt.outgoing.Negate(), faceForward(normal, tt.outgoing)); !want.Equals(incoming) {
+						t.Fatalf("Expected reflected direction %v, got %v", want, incoming)
+					}
+				} else if !tt.refracted.Equals(incoming) {
+					t.Fatalf("Expected refracted direction %v, got %v", tt.refracted, incoming)
+				}
+			}
+
+			if got := float64(reflections) / float64(samples); math.Abs(got-tt.reflected) > 0.01 {
+				t.Errorf("Expected %v of the light to be reflected, got %v", tt.reflected, got)
+			}
+		})
+	}
+}
+
+func TestDielectricFresnel(t *testing.T) {
+	// Head on, glass reflects ((1.5 - 1) / (1.5 + 1))^2 of the light.
+	if got := dielectricFresnel(1, 1, 1/1.5); !Float64Equal(got, 0.04) {
+		t.Errorf("Expected reflectance 0.04, got %v", got)
+	}
+
+	// Light at a grazing angle is totally reflected.
+	if got := dielectricFresnel(0, math.Sqrt(1-1/2.25), 1/1.5); !Float64Equal(got, 1) {
+		t.Errorf("Expected reflectance 1, got %v", got)
+	}
+}
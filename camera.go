@@ -16,6 +16,22 @@ type Camera struct {
 	// camera.
 	Transform Matrix
 
+	// The radius of the camera's lens in world-space units. A camera with no
+	// aperture is a perfect pinhole camera and everything is in focus.
+	Aperture float64
+	// The distance from the camera to the plane that is in perfect focus.
+	FocalDistance float64
+
+	// The projection that maps points on the camera's canvas to rays.
+	Projection Projection
+
+	// The times at which the camera's shutter opens and closes. Rays are cast
+	// at times spread across this interval so that moving objects are blurred.
+	// If the shutter closes at the same time it opens, every ray is cast at
+	// the moment the shutter opens.
+	ShutterOpen  float64
+	ShutterClose float64
+
 	// Half the width of the view in world-space units.
 	halfWidth float64
 	// Half the height of the view in world-space units.
@@ -26,38 +42,98 @@ type Camera struct {
 
 func MakeCamera(width, height int, fov float64) Camera {
 	camera := Camera{
-		Width:       width,
-		Height:      height,
-		FieldOfView: fov,
-		Transform:   IdentityMatrix4,
+		Width:         width,
+		Height:        height,
+		FieldOfView:   fov,
+		Transform:     IdentityMatrix4,
+		FocalDistance: 1,
+		Projection:    PerspectiveProjection{},
 	}
 	camera.computeCameraPixelSize()
 
 	return camera
 }
 
+// Create a ray that passes from the camera through the center of the given
+// pixel.
 func (c Camera) MakeRayForPixel(x, y int) Ray {
-	// Compute offsets from the edge of the canvas to the pixel's center.
-	offsetX := (float64(x) + 0.5) * c.pixelSize
-	offsetY := (float64(y) + 0.5) * c.pixelSize
+	return c.MakeRayForPixelOffset(x, y, 0.5, 0.5)
+}
 
-	// The untransformed coordinates of the pixel in world-space.
-	worldX := c.halfWidth - offsetX
-	worldY := c.halfHeight - offsetY
+// Create a ray that passes from the center of the camera's lens through a
+// specific point within the given pixel. The offsets are given in pixel units
+// measured from the pixel's top left corner, so an offset of (0.5, 0.5) is the
+// center of the pixel.
+func (c Camera) MakeRayForPixelOffset(x, y int, pixelOffsetX, pixelOffsetY float64) Ray {
+	return c.MakeRayForSample(x, y, CameraSample{
+		PixelX: pixelOffsetX,
+		PixelY: pixelOffsetY,
+		LensU:  0.5,
+		LensV:  0.5,
+	})
+}
+
+// Create a ray for the given pixel using the positions described by a camera
+// sample. If the camera has an aperture, the ray starts at the sampled point on
+// the lens and is aimed so that it passes through the same point on the focal
+// plane as the ray through the center of the lens would.
+func (c Camera) MakeRayForSample(x, y int, sample CameraSample) Ray {
+	ray := c.projection().CameraRay(
+		c,
+		float64(x)+sample.PixelX,
+		float64(y)+sample.PixelY,
+	)
+
+	// The lens only focuses rays heading towards the focal plane in front of
+	// the camera. Anything else, such as the rear half of a panorama, is
+	// treated as if it passed through a pinhole.
+	if c.Aperture > 0 && ray.Direction.Z < 0 {
+		focalPoint := ray.Position(c.FocalDistance / -ray.Direction.Z)
+		lensX, lensY := sampleUnitDisk(sample.LensU, sample.LensV)
+		origin := ray.Origin.Add(MakeVector(lensX*c.Aperture, lensY*c.Aperture, 0))
+		ray = MakeRay(origin, focalPoint.Subtract(origin))
+	}
 
-	// Transform the canvas point and origin, remembering that the canvas is at
-	// z = -1.
+	// Transform the ray from camera space into world space.
 	inverseCameraTransform := c.Transform.Inverted()
-	pixel := inverseCameraTransform.TupleMultiply(MakePoint(worldX, worldY, -1))
-	origin := inverseCameraTransform.TupleMultiply(MakePoint(0, 0, 0))
-	direction := pixel.Subtract(origin).Normalized()
+	origin := inverseCameraTransform.TupleMultiply(ray.Origin)
+	direction := inverseCameraTransform.TupleMultiply(ray.Direction).Normalized()
+	time := c.ShutterOpen + sample.Time*(c.ShutterClose-c.ShutterOpen)
+
+	return MakeRayAtTime(origin, direction, time)
+}
+
+// Get the projection used by the camera. Cameras without an explicit
+// projection use a perspective projection.
+func (c Camera) projection() Projection {
+	if c.Projection == nil {
+		return PerspectiveProjection{}
+	}
+
+	return c.Projection
+}
+
+// The positions used to generate a single ray from a camera.
+type CameraSample struct {
+	// The offset of the sample within its pixel, in pixel units measured from
+	// the pixel's top left corner.
+	PixelX float64
+	PixelY float64
+
+	// A point in the unit square that is mapped onto the camera's lens. The
+	// point (0.5, 0.5) is the center of the lens.
+	LensU float64
+	LensV float64
 
-	return MakeRay(origin, direction)
+	// The fraction of the shutter interval that has elapsed when the ray is
+	// cast, in the range [0, 1].
+	Time float64
 }
 
 // Compute the size of a pixel in world-space units for a camera with the given
 // width and height. The camera assumes that the canvas is exactly one world-
-// space unit away from the camera.
+// space unit away from the camera. These sizes are used by the perspective
+// projection.
 func (c *Camera) computeCameraPixelSize() {
 	// Compute the size of half the view in world-space units. This can
 	// represent either half the view's height or half the view's width
@@ -111,3 +111,148 @@ func TestCamera_MakeRayForPixel(t *testing.T) {
 		})
 	}
 }
+
+func TestCamera_MakeRayForPixelOffset(t *testing.T) {
+	camera := MakeCamera(201, 101, math.Pi/2)
+
+	testCases := []struct {
+		name    string
+		x       int
+		y       int
+		offsetX float64
+		offsetY float64
+		want    Ray
+	}{
+		{
+			"pixel center matches MakeRayForPixel",
+			0,
+			0,
+			0.5,
+			0.5,
+			camera.MakeRayForPixel(0, 0),
+		},
+		{
+			"offset into neighboring pixel",
+			99,
+			50,
+			1.5,
+			0.5,
+			camera.MakeRayForPixel(100, 50),
+		},
+		{
+			"top left corner of canvas",
+			0,
+			0,
+			0,
+			0,
+			MakeRay(
+				MakePoint(0, 0, 0),
+				MakeVector(1, 101.0/201, -1).Normalized(),
+			),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := camera.MakeRayForPixelOffset(tt.x, tt.y, tt.offsetX, tt.offsetY)
+
+			if !tt.want.Direction.Equals(got.Direction) {
+				t.Errorf("Expected ray direction %v, got %v", tt.want.Direction, got.Direction)
+			}
+
+			if !tt.want.Origin.Equals(got.Origin) {
+				t.Errorf("Expected ray origin %v, got %v", tt.want.Origin, got.Origin)
+			}
+		})
+	}
+}
+
+func TestCamera_MakeRayForSample_ThinLens(t *testing.T) {
+	camera := MakeCamera(201, 101, math.Pi/2)
+	camera.Transform = MakeTranslation(0, 0, -3)
+	camera.Aperture = 0.5
+	camera.FocalDistance = 4
+
+	pinhole := camera
+	pinhole.Aperture = 0
+
+	testCases := []struct {
+		name       string
+		sample     CameraSample
+		wantOrigin Tuple
+	}{
+		{
+			"center of lens",
+			CameraSample{PixelX: 0.5, PixelY: 0.5, LensU: 0.5, LensV: 0.5},
+			MakePoint(0, 0, 3),
+		},
+		{
+			"edge of lens",
+			CameraSample{PixelX: 0.5, PixelY: 0.5, LensU: 1, LensV: 0.5},
+			MakePoint(0.5, 0, 3),
+		},
+		{
+			"top of lens",
+			CameraSample{PixelX: 0.2, PixelY: 0.7, LensU: 0.5, LensV: 1},
+			MakePoint(0, 0.5, 3),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := camera.MakeRayForSample(30, 20, tt.sample)
+
+			if !tt.wantOrigin.Equals(got.Origin) {
+				t.Errorf("Expected ray origin %v, got %v", tt.wantOrigin, got.Origin)
+			}
+
+			// Every ray through the lens should converge with the pinhole ray
+			// on the focal plane.
+			pinholeRay := pinhole.MakeRayForSample(30, 20, tt.sample)
+			want := pinholeRay.Position(-camera.FocalDistance / pinholeRay.Direction.Z)
+			focus := got.Position(-camera.FocalDistance / got.Direction.Z)
+			if !want.Equals(focus) {
+				t.Errorf("Expected ray to reach the focal plane at %v, got %v", want, focus)
+			}
+		})
+	}
+}
+
+// A camera without an aperture should ignore the lens sample entirely.
+func TestCamera_MakeRayForSample_Pinhole(t *testing.T) {
+	camera := MakeCamera(201, 101, math.Pi/2)
+	camera.FocalDistance = 10
+
+	want := camera.MakeRayForPixel(10, 10)
+	got := camera.MakeRayForSample(10, 10, CameraSample{PixelX: 0.5, PixelY: 0.5, LensU: 0.9, LensV: 0.1})
+
+	if !want.Origin.Equals(got.Origin) || !want.Direction.Equals(got.Direction) {
+		t.Errorf("Expected ray %v, got %v", want, got)
+	}
+}
+
+func TestCamera_MakeRayForSample_Shutter(t *testing.T) {
+	testCases := []struct {
+		name         string
+		shutterOpen  float64
+		shutterClose float64
+		sampleTime   float64
+		want         float64
+	}{
+		{"shutter closed", 0, 0, 0.5, 0},
+		{"start of interval", 0, 1, 0, 0},
+		{"middle of interval", 2, 4, 0.5, 3},
+		{"end of interval", 2, 4, 1, 4},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			camera := MakeCamera(11, 11, math.Pi/2)
+			camera.ShutterOpen = tt.shutterOpen
+			camera.ShutterClose = tt.shutterClose
+
+			ray := camera.MakeRayForSample(5, 5, CameraSample{PixelX: 0.5, PixelY: 0.5, LensU: 0.5, LensV: 0.5, Time: tt.sampleTime})
+
+			if !Float64Equal(tt.want, ray.Time) {
+				t.Errorf("Expected ray time %v, got %v", tt.want, ray.Time)
+			}
+		})
+	}
+}
@@ -2,12 +2,19 @@ package main
 
 // A canvas is a 2D array of pixels. A canvas' X-coordinates lie in the range
 // [0, width) and its Y-coordinates lie in the range [0, height).
+//
+// Each pixel also has an alpha value giving how much of the pixel is covered,
+// from 0 for fully transparent to 1 for fully opaque. Colors are stored
+// premultiplied by alpha, so a canvas' colors are always the same as the
+// canvas composited over black.
 type Canvas struct {
 	Width  int
 	Height int
 
 	// The 2D array backing the canvas.
 	colors [][]Color
+	// The 2D array of alpha values, stored in the same order as the colors.
+	alphas [][]float64
 }
 
 // Get the color of a specific pixel on the canvas.
@@ -20,18 +27,53 @@ func (c *Canvas) SetPixel(x, y int, color Color) {
 	c.colors[x][y] = color
 }
 
-// Create a new canvas with the specified dimensions.
+// Get the alpha value of a specific pixel on the canvas.
+func (c Canvas) GetAlpha(x, y int) float64 {
+	return c.alphas[x][y]
+}
+
+// Set the alpha value of a specific pixel on the canvas. The pixel's color
+// should already be premultiplied by the alpha value.
+func (c *Canvas) SetAlpha(x, y int, alpha float64) {
+	c.alphas[x][y] = alpha
+}
+
+// Create a new canvas with the specified dimensions. Every pixel starts out
+// black and fully opaque.
 func MakeCanvas(width, height int) Canvas {
 	// We store colors in column-major order so that we can access normally with
 	// (x, y)-like syntax.
 	columns := make([][]Color, width)
+	alphas := make([][]float64, width)
 	for x := range columns {
 		columns[x] = make([]Color, height)
+		alphas[x] = make([]float64, height)
+		for y := range alphas[x] {
+			alphas[x][y] = 1
+		}
 	}
 
 	return Canvas{
 		width,
 		height,
 		columns,
+		alphas,
 	}
 }
+
+// Create a copy of the canvas with colors that are not premultiplied by alpha.
+// Fully transparent pixels are black. The copy's alpha values are unchanged.
+func (c Canvas) Unpremultiplied() Canvas {
+	straight := MakeCanvas(c.Width, c.Height)
+	for y := 0; y < c.Height; y++ {
+		for x := 0; x < c.Width; x++ {
+			alpha := c.GetAlpha(x, y)
+			straight.SetAlpha(x, y, alpha)
+			if alpha > 0 {
+				straight.SetPixel(x, y, c.GetPixel(x, y).Multiply(1/alpha))
+			}
+		}
+	}
+
+	return straight
+}
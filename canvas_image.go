@@ -0,0 +1,139 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// The default quality used when writing JPEGs, in the range [1, 100].
+const JPEGDefaultQuality = 90
+
+// Get the color model of the canvas when it is used as an image. Canvases
+// present their colors as 16-bit RGBA values.
+func (c Canvas) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+// Get the bounds of the canvas when it is used as an image.
+func (c Canvas) Bounds() image.Rectangle {
+	return image.Rect(0, 0, c.Width, c.Height)
+}
+
+// Get the color of a pixel when the canvas is used as an image. Intensities
+// outside the range [0, 1] are clamped, and pixels outside the canvas are
+// transparent. Colors are clamped to the pixel's alpha so that they remain
+// valid premultiplied values.
+func (c Canvas) At(x, y int) color.Color {
+	if !(image.Point{x, y}.In(c.Bounds())) {
+		return color.RGBA64{}
+	}
+
+	pixel := c.GetPixel(x, y)
+	alpha := scaleToMaxValue(c.GetAlpha(x, y), 0xffff)
+	channel := func(value float64) uint16 {
+		scaled := scaleToMaxValue(value, 0xffff)
+		if scaled > alpha {
+			scaled = alpha
+		}
+
+		return uint16(scaled)
+	}
+
+	return color.RGBA64{
+		R: channel(pixel.Red()),
+		G: channel(pixel.Green()),
+		B: channel(pixel.Blue()),
+		A: uint16(alpha),
+	}
+}
+
+// Create a canvas with the contents of an image, including its alpha values.
+func MakeCanvasFromImage(source image.Image) Canvas {
+	bounds := source.Bounds()
+	canvas := MakeCanvas(bounds.Dx(), bounds.Dy())
+
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			// The values are premultiplied by alpha, which is how canvases
+			// store their colors.
+			r, g, b, a := source.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			canvas.SetPixel(x, y, MakeColor(
+				float64(r)/0xffff,
+				float64(g)/0xffff,
+				float64(b)/0xffff,
+			))
+			canvas.SetAlpha(x, y, float64(a)/0xffff)
+		}
+	}
+
+	return canvas
+}
+
+// Options controlling how a canvas is written as a PNG.
+type PNGOptions struct {
+	// The transform applied to colors before they are quantized.
+	Transform OutputTransform
+	// The dithering applied while quantizing colors.
+	Dither Dither
+}
+
+// Create PNG options that write colors linearly.
+func MakePNGOptions() PNGOptions {
+	return PNGOptions{Transform: MakeOutputTransform()}
+}
+
+// Options controlling how a canvas is written as a JPEG.
+type JPEGOptions struct {
+	// The quality of the compressed image in the range [1, 100].
+	Quality int
+	// The transform applied to colors before they are quantized.
+	Transform OutputTransform
+}
+
+// Create JPEG options that write colors linearly with the default quality.
+func MakeJPEGOptions() JPEGOptions {
+	return JPEGOptions{Quality: JPEGDefaultQuality, Transform: MakeOutputTransform()}
+}
+
+// Write the contents of a canvas as an 8-bit PNG with an alpha channel.
+func WriteCanvasToPNG(canvas Canvas, dest io.Writer) error {
+	return WriteCanvasToPNGWithOptions(canvas, dest, MakePNGOptions())
+}
+
+// Write the contents of a canvas as an 8-bit PNG using the given options.
+func WriteCanvasToPNGWithOptions(canvas Canvas, dest io.Writer, options PNGOptions) error {
+	return png.Encode(dest, quantizeCanvas(canvas, options.Transform, options.Dither))
+}
+
+// Write the contents of a canvas as a JPEG using the given options. JPEGs have
+// no alpha channel, so transparent pixels are composited over black.
+func WriteCanvasToJPEG(canvas Canvas, dest io.Writer, options JPEGOptions) error {
+	quantized := quantizeCanvas(canvas, options.Transform, Dither{Method: DitherNone})
+
+	return jpeg.Encode(dest, quantized, &jpeg.Options{Quality: options.Quality})
+}
+
+// Convert a canvas to an image with 8-bit color values. The values are
+// transformed and quantized the same way they are for PPMs, but colors are
+// unpremultiplied first since transforms are only valid for straight colors.
+func quantizeCanvas(canvas Canvas, transform OutputTransform, dither Dither) *image.NRGBA {
+	values := quantizeCanvasValues(canvas.Unpremultiplied(), transform, 255, dither)
+	quantized := image.NewNRGBA(canvas.Bounds())
+
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			index := (y*canvas.Width + x) * 3
+			quantized.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(values[index]),
+				G: uint8(values[index+1]),
+				B: uint8(values[index+2]),
+				A: uint8(scaleToMaxValue(canvas.GetAlpha(x, y), 0xff)),
+			})
+		}
+	}
+
+	return quantized
+}
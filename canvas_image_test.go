@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+)
+
+func TestCanvas_Image(t *testing.T) {
+	canvas := MakeCanvas(3, 2)
+	canvas.SetPixel(1, 1, MakeColor(1, 0.5, 2))
+
+	var img image.Image = canvas
+
+	if want, got := image.Rect(0, 0, 3, 2), img.Bounds(); want != got {
+		t.Errorf("Expected bounds %v, got %v", want, got)
+	}
+
+	if got := img.ColorModel(); got != color.RGBA64Model {
+		t.Errorf("Expected RGBA64 color model, got %v", got)
+	}
+
+	testCases := []struct {
+		name string
+		x    int
+		y    int
+		want color.RGBA64
+	}{
+		{"black pixel", 0, 0, color.RGBA64{0, 0, 0, 0xffff}},
+		{"colored pixel", 1, 1, color.RGBA64{0xffff, 0x8000, 0xffff, 0xffff}},
+		{"outside canvas", 3, 0, color.RGBA64{}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := img.At(tt.x, tt.y); got != tt.want {
+				t.Errorf("Expected At(%d, %d) = %v, got %v", tt.x, tt.y, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMakeCanvasFromImage(t *testing.T) {
+	source := image.NewNRGBA(image.Rect(10, 20, 12, 21))
+	source.SetNRGBA(10, 20, color.NRGBA{255, 0, 51, 255})
+	source.SetNRGBA(11, 20, color.NRGBA{255, 255, 255, 0x80})
+
+	canvas := MakeCanvasFromImage(source)
+
+	if canvas.Width != 2 || canvas.Height != 1 {
+		t.Fatalf("Expected a 2x1 canvas, got %dx%d", canvas.Width, canvas.Height)
+	}
+
+	if want, got := MakeColor(1, 0, 0.2), canvas.GetPixel(0, 0); !want.Equals(got) {
+		t.Errorf("Expected pixel (0, 0) to be %v, got %v", want, got)
+	}
+
+	// Half transparent white over black is gray.
+	if got := canvas.GetPixel(1, 0).Red(); math.Abs(got-0x80/255.0) > 0.001 {
+		t.Errorf("Expected pixel (1, 0) to be gray, got %v", canvas.GetPixel(1, 0))
+	}
+
+	if got := canvas.GetAlpha(1, 0); math.Abs(got-0x80/255.0) > 0.001 {
+		t.Errorf("Expected pixel (1, 0) to have alpha 0.5, got %v", got)
+	}
+}
+
+func TestWriteCanvasToPNG_Alpha(t *testing.T) {
+	source := MakeCanvas(2, 1)
+	source.SetPixel(0, 0, MakeColor(0.25, 0.5, 0))
+	source.SetAlpha(0, 0, 0.5)
+	source.SetAlpha(1, 0, 0)
+
+	var buffer bytes.Buffer
+	if err := WriteCanvasToPNG(source, &buffer); err != nil {
+		t.Fatalf("WriteCanvasToPNG() returned error: %v", err)
+	}
+
+	decoded, err := png.Decode(&buffer)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+
+	// PNGs store colors that are not premultiplied.
+	want := []color.NRGBA{{128, 255, 0, 128}, {0, 0, 0, 0}}
+	for x, wantColor := range want {
+		if got := color.NRGBAModel.Convert(decoded.At(x, 0)); got != wantColor {
+			t.Errorf("Expected pixel (%d, 0) to be %v, got %v", x, wantColor, got)
+		}
+	}
+}
+
+func TestWriteCanvasToPNG(t *testing.T) {
+	source := MakeCanvas(4, 3)
+	source.SetPixel(0, 0, MakeColor(1, 0, 0))
+	source.SetPixel(3, 2, MakeColor(0, 0.5, 1))
+
+	var buffer bytes.Buffer
+	if err := WriteCanvasToPNG(source, &buffer); err != nil {
+		t.Fatalf("WriteCanvasToPNG() returned error: %v", err)
+	}
+
+	decoded, err := png.Decode(&buffer)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+
+	got := MakeCanvasFromImage(decoded)
+	for y := 0; y < source.Height; y++ {
+		for x := 0; x < source.Width; x++ {
+			want := source.GetPixel(x, y)
+			if diff := want.Subtract(got.GetPixel(x, y)); math.Abs(diff.Red()) > 1.0/255 ||
+				math.Abs(diff.Green()) > 1.0/255 ||
+				math.Abs(diff.Blue()) > 1.0/255 {
+				t.Errorf("Expected pixel (%d, %d) to be %v, got %v", x, y, want, got.GetPixel(x, y))
+			}
+		}
+	}
+}
+
+func TestWriteCanvasToJPEG(t *testing.T) {
+	source := MakeCanvas(16, 8)
+	for y := 0; y < source.Height; y++ {
+		for x := 0; x < source.Width; x++ {
+			source.SetPixel(x, y, MakeColor(0.5, 0.5, 0.5))
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := WriteCanvasToJPEG(source, &buffer, MakeJPEGOptions()); err != nil {
+		t.Fatalf("WriteCanvasToJPEG() returned error: %v", err)
+	}
+
+	decoded, err := jpeg.Decode(&buffer)
+	if err != nil {
+		t.Fatalf("Failed to decode JPEG: %v", err)
+	}
+
+	if want, got := source.Bounds(), decoded.Bounds(); want != got {
+		t.Errorf("Expected bounds %v, got %v", want, got)
+	}
+
+	if got := MakeCanvasFromImage(decoded).GetPixel(4, 4).Green(); math.Abs(got-0.5) > 0.02 {
+		t.Errorf("Expected a mid gray pixel, got %v", got)
+	}
+}
+
+func TestWriteCanvasToPNGWithOptions_Transform(t *testing.T) {
+	source := MakeCanvas(1, 1)
+	source.SetPixel(0, 0, MakeColor(0.5, 0.25, 0))
+
+	options := MakePNGOptions()
+	options.Transform.Exposure = 1
+	options.Transform.SRGB = true
+
+	var buffer bytes.Buffer
+	if err := WriteCanvasToPNGWithOptions(source, &buffer, options); err != nil {
+		t.Fatalf("WriteCanvasToPNGWithOptions() returned error: %v", err)
+	}
+
+	decoded, err := png.Decode(&buffer)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+
+	want := color.NRGBA{255, 188, 0, 255}
+	if got := color.NRGBAModel.Convert(decoded.At(0, 0)); got != want {
+		t.Errorf("Expected pixel %v, got %v", want, got)
+	}
+}
@@ -75,3 +75,36 @@ func TestMakeCanvas(t *testing.T) {
 		}
 	}
 }
+
+func TestMakeCanvas_Opaque(t *testing.T) {
+	canvas := MakeCanvas(3, 2)
+
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			if got := canvas.GetAlpha(x, y); got != 1 {
+				t.Errorf("Expected pixel (%d, %d) to be opaque, got alpha %v", x, y, got)
+			}
+		}
+	}
+}
+
+func TestCanvas_Unpremultiplied(t *testing.T) {
+	canvas := MakeCanvas(2, 1)
+	canvas.SetPixel(0, 0, MakeColor(0.25, 0.125, 0))
+	canvas.SetAlpha(0, 0, 0.5)
+	canvas.SetAlpha(1, 0, 0)
+
+	got := canvas.Unpremultiplied()
+
+	if want := MakeColor(0.5, 0.25, 0); !want.Equals(got.GetPixel(0, 0)) {
+		t.Errorf("Expected pixel (0, 0) to be %v, got %v", want, got.GetPixel(0, 0))
+	}
+
+	if alpha := got.GetAlpha(0, 0); alpha != 0.5 {
+		t.Errorf("Expected pixel (0, 0) to keep alpha 0.5, got %v", alpha)
+	}
+
+	if want := MakeColor(0, 0, 0); !want.Equals(got.GetPixel(1, 0)) {
+		t.Errorf("Expected transparent pixel to be %v, got %v", want, got.GetPixel(1, 0))
+	}
+}
@@ -38,6 +38,12 @@ func (c Color) Green() float64 {
 	return c.tuple.Y
 }
 
+// Get the perceived brightness of the color, weighting each channel by how
+// sensitive the eye is to it using the Rec. 709 coefficients.
+func (c Color) Luminance() float64 {
+	return 0.2126*c.Red() + 0.7152*c.Green() + 0.0722*c.Blue()
+}
+
 // Multiply the color by a scalar factor.
 func (c Color) Multiply(factor float64) Color {
 	return Color{c.tuple.Multiply(factor)}
@@ -27,6 +27,24 @@ func TestColor_Add(t *testing.T) {
 	}
 }
 
+func TestColor_Luminance(t *testing.T) {
+	testCases := []struct {
+		color Color
+		want  float64
+	}{
+		{MakeColor(1, 1, 1), 1},
+		{MakeColor(0, 0, 0), 0},
+		{MakeColor(1, 0, 0), 0.2126},
+		{MakeColor(0, 2, 0), 1.4304},
+		{MakeColor(0, 0, 1), 0.0722},
+	}
+	for _, tt := range testCases {
+		if got := tt.color.Luminance(); !Float64Equal(got, tt.want) {
+			t.Errorf("Expected the luminance of %v to be %v, got %v", tt.color, tt.want, got)
+		}
+	}
+}
+
 func TestColor_Blend(t *testing.T) {
 	testCases := []colorOpTestCase{
 		{
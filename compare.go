@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// The difference between two images of the same size.
+type ImageDifference struct {
+	// The root mean square error of every color channel.
+	RMSE float64
+	// The peak signal-to-noise ratio in decibels, assuming a peak intensity of
+	// 1. Identical images have an infinite ratio.
+	PSNR float64
+	// The largest difference of any color channel of any pixel.
+	MaxError float64
+	// The number of pixels with a channel that differs by more than the
+	// tolerance.
+	DifferingPixels int
+
+	// An image of where the images differ. Pixels within the tolerance are
+	// black, and the rest range from dark red for small differences through
+	// yellow to white for the largest difference.
+	Heatmap Canvas
+}
+
+// Determine if every pixel of the images is within the tolerance.
+func (d ImageDifference) Matches() bool {
+	return d.DifferingPixels == 0
+}
+
+// Compare two canvases pixel by pixel. A pixel differs if any of its color
+// channels differ by more than the tolerance. An error is returned if the
+// canvases are not the same size.
+func CompareCanvases(a, b Canvas, tolerance float64) (ImageDifference, error) {
+	if a.Width != b.Width || a.Height != b.Height {
+		return ImageDifference{}, fmt.Errorf(
+			"cannot compare a %dx%d image to a %dx%d image",
+			a.Width, a.Height, b.Width, b.Height,
+		)
+	}
+
+	var difference ImageDifference
+	pixelErrors := make([]float64, a.Width*a.Height)
+	sumSquares := 0.0
+
+	for y := 0; y < a.Height; y++ {
+		for x := 0; x < a.Width; x++ {
+			diff := a.GetPixel(x, y).Subtract(b.GetPixel(x, y))
+
+			pixelError := 0.0
+			for _, channel := range []float64{diff.Red(), diff.Green(), diff.Blue()} {
+				sumSquares += channel * channel
+				pixelError = math.Max(pixelError, math.Abs(channel))
+			}
+
+			pixelErrors[y*a.Width+x] = pixelError
+			difference.MaxError = math.Max(difference.MaxError, pixelError)
+			if pixelError > tolerance {
+				difference.DifferingPixels++
+			}
+		}
+	}
+
+	mse := sumSquares / float64(a.Width*a.Height*3)
+	difference.RMSE = math.Sqrt(mse)
+	difference.PSNR = math.Inf(1)
+	if mse > 0 {
+		difference.PSNR = 10 * math.Log10(1/mse)
+	}
+
+	difference.Heatmap = MakeCanvas(a.Width, a.Height)
+	for y := 0; y < a.Height; y++ {
+		for x := 0; x < a.Width; x++ {
+			pixelError := pixelErrors[y*a.Width+x]
+			if pixelError <= tolerance {
+				difference.Heatmap.SetPixel(x, y, MakeColor(0, 0, 0))
+				continue
+			}
+
+			difference.Heatmap.SetPixel(x, y, heatmapColor(pixelError/difference.MaxError))
+		}
+	}
+
+	return difference, nil
+}
+
+// Get the color of a heatmap for an amount in the range (0, 1]. The color
+// ramps from dark red through red and yellow up to white.
+func heatmapColor(amount float64) Color {
+	clamp := func(value float64) float64 {
+		return math.Max(0, math.Min(1, value))
+	}
+
+	return MakeColor(
+		clamp(0.25+amount*2.25),
+		clamp(amount*3-1),
+		clamp(amount*3-2),
+	)
+}
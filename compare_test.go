@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompareCanvases(t *testing.T) {
+	a := MakeCanvas(2, 2)
+	b := MakeCanvas(2, 2)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			a.SetPixel(x, y, MakeColor(0.5, 0.5, 0.5))
+			b.SetPixel(x, y, MakeColor(0.5, 0.5, 0.5))
+		}
+	}
+	b.SetPixel(1, 0, MakeColor(0.5, 0.9, 0.5))
+	b.SetPixel(0, 1, MakeColor(0.51, 0.5, 0.5))
+
+	got, err := CompareCanvases(a, b, 0.05)
+	if err != nil {
+		t.Fatalf("CompareCanvases() returned error: %v", err)
+	}
+
+	wantRMSE := math.Sqrt((0.4*0.4 + 0.01*0.01) / 12)
+	if !Float64Equal(wantRMSE, got.RMSE) {
+		t.Errorf("Expected RMSE %v, got %v", wantRMSE, got.RMSE)
+	}
+
+	wantPSNR := 10 * math.Log10(1/(wantRMSE*wantRMSE))
+	if !Float64Equal(wantPSNR, got.PSNR) {
+		t.Errorf("Expected PSNR %v, got %v", wantPSNR, got.PSNR)
+	}
+
+	if !Float64Equal(0.4, got.MaxError) {
+		t.Errorf("Expected max error 0.4, got %v", got.MaxError)
+	}
+
+	// Only the pixel outside the tolerance differs.
+	if got.DifferingPixels != 1 || got.Matches() {
+		t.Errorf("Expected 1 differing pixel, got %d", got.DifferingPixels)
+	}
+
+	if color := got.Heatmap.GetPixel(1, 0); !color.Equals(MakeColor(1, 1, 1)) {
+		t.Errorf("Expected the largest difference to be white in the heatmap, got %v", color)
+	}
+
+	for _, pixel := range [][2]int{{0, 0}, {0, 1}, {1, 1}} {
+		if color := got.Heatmap.GetPixel(pixel[0], pixel[1]); !color.Equals(MakeColor(0, 0, 0)) {
+			t.Errorf("Expected pixel %v to be black in the heatmap, got %v", pixel, color)
+		}
+	}
+}
+
+func TestCompareCanvases_Identical(t *testing.T) {
+	canvas := MakeCanvas(3, 2)
+
+	got, err := CompareCanvases(canvas, canvas, 0)
+	if err != nil {
+		t.Fatalf("CompareCanvases() returned error: %v", err)
+	}
+
+	if !got.Matches() || got.RMSE != 0 || !math.IsInf(got.PSNR, 1) {
+		t.Errorf("Expected identical canvases to match with infinite PSNR, got %+v", got)
+	}
+}
+
+func TestCompareCanvases_DifferentSizes(t *testing.T) {
+	if _, err := CompareCanvases(MakeCanvas(2, 2), MakeCanvas(2, 3), 0); err == nil {
+		t.Error("Expected an error comparing canvases of different sizes")
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// A dithering method hides the banding caused by quantizing smooth gradients
+// by adding structured noise to the values before they are rounded.
+type DitherMethod int
+
+const (
+	// Values are rounded to the nearest level.
+	DitherNone DitherMethod = iota
+	// Values are offset by a repeating 8x8 Bayer matrix, which produces a
+	// regular cross-hatched pattern.
+	DitherBayer
+	// Values are offset by a repeating blue noise mask, which produces an
+	// even, unstructured grain.
+	DitherBlueNoise
+	// The rounding error of each value is pushed onto its unprocessed
+	// neighbors.
+	DitherFloydSteinberg
+)
+
+// The size of the tiles the blue noise mask is generated for.
+const blueNoiseSize = 32
+
+// The 8x8 Bayer matrix. Each entry is the order in which the corresponding
+// position turns on as the value increases.
+var bayerMatrix = [8][8]int{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// Options controlling how values are dithered when they are quantized.
+type Dither struct {
+	// The dithering method to use.
+	Method DitherMethod
+	// The seed that determines the placement of the ordered dithering
+	// patterns. The same seed always produces the same output. Floyd-Steinberg
+	// dithering does not depend on the seed.
+	Seed int64
+}
+
+// Parse the name of a dithering method as it would be given on the command
+// line.
+func ParseDitherMethod(name string) (DitherMethod, error) {
+	switch name {
+	case "none":
+		return DitherNone, nil
+	case "bayer":
+		return DitherBayer, nil
+	case "blue-noise":
+		return DitherBlueNoise, nil
+	case "floyd-steinberg":
+		return DitherFloydSteinberg, nil
+	}
+
+	return DitherNone, fmt.Errorf("unknown dithering method '%s'", name)
+}
+
+// Get the name of the dithering method.
+func (m DitherMethod) String() string {
+	switch m {
+	case DitherNone:
+		return "none"
+	case DitherBayer:
+		return "bayer"
+	case DitherBlueNoise:
+		return "blue-noise"
+	case DitherFloydSteinberg:
+		return "floyd-steinberg"
+	}
+
+	return fmt.Sprintf("DitherMethod(%d)", int(m))
+}
+
+// Quantize the colors of a canvas to integers in the range [0, maxValue]. The
+// colors are transformed for display and then dithered. The values are
+// returned in row-major order with the red, green, and blue values of each
+// pixel next to each other.
+func quantizeCanvasValues(canvas Canvas, transform OutputTransform, maxValue int, dither Dither) []int {
+	// Work with values scaled to the output range so that a difference of 1
+	// is a single quantization level.
+	scaled := make([]float64, canvas.Width*canvas.Height*3)
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			color := transform.Apply(canvas.GetPixel(x, y))
+			index := (y*canvas.Width + x) * 3
+
+			scaled[index] = color.Red() * float64(maxValue)
+			scaled[index+1] = color.Green() * float64(maxValue)
+			scaled[index+2] = color.Blue() * float64(maxValue)
+		}
+	}
+
+	switch dither.Method {
+	case DitherBayer:
+		return quantizeOrdered(scaled, canvas.Width, maxValue, makeBayerThresholds(dither.Seed))
+	case DitherBlueNoise:
+		return quantizeOrdered(scaled, canvas.Width, maxValue, makeBlueNoiseThresholds(dither.Seed))
+	case DitherFloydSteinberg:
+		return quantizeFloydSteinberg(scaled, canvas.Width, maxValue)
+	}
+
+	values := make([]int, len(scaled))
+	for i, value := range scaled {
+		values[i] = clampLevel(int(math.Round(value)), maxValue)
+	}
+
+	return values
+}
+
+// A square, tileable matrix of thresholds in the range (0, 1).
+type thresholdMatrix struct {
+	size       int
+	thresholds []float64
+}
+
+// Get the threshold for a pixel, tiling the matrix across the image.
+func (m thresholdMatrix) at(x, y int) float64 {
+	return m.thresholds[(y%m.size)*m.size+x%m.size]
+}
+
+// Quantize values using ordered dithering. Each value is offset by the
+// threshold of its pixel before being rounded down, so a value that is a
+// fraction of the way between two levels is rounded up in that fraction of the
+// pixels.
+func quantizeOrdered(scaled []float64, width int, maxValue int, matrix thresholdMatrix) []int {
+	values := make([]int, len(scaled))
+	for i, value := range scaled {
+		pixel := i / 3
+		threshold := matrix.at(pixel%width, pixel/width)
+		values[i] = clampLevel(int(math.Floor(value+threshold)), maxValue)
+	}
+
+	return values
+}
+
+// Quantize values using Floyd-Steinberg error diffusion. Pixels are processed
+// from left to right and top to bottom, with the error from rounding each value
+// spread to the neighbors to its right and below it.
+func quantizeFloydSteinberg(scaled []float64, width int, maxValue int) []int {
+	if width == 0 || len(scaled) == 0 {
+		return []int{}
+	}
+
+	height := len(scaled) / 3 / width
+	diffused := make([]float64, len(scaled))
+	values := make([]int, len(scaled))
+
+	spread := func(x, y, channel int, amount float64) {
+		if x < 0 || x >= width || y >= height {
+			return
+		}
+
+		diffused[(y*width+x)*3+channel] += amount
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			for channel := 0; channel < 3; channel++ {
+				index := (y*width+x)*3 + channel
+				value := scaled[index] + diffused[index]
+				level := clampLevel(int(math.Round(value)), maxValue)
+				values[index] = level
+
+				diff := value - float64(level)
+				spread(x+1, y, channel, diff*7/16)
+				spread(x-1, y+1, channel, diff*3/16)
+				spread(x, y+1, channel, diff*5/16)
+				spread(x+1, y+1, channel, diff*1/16)
+			}
+		}
+	}
+
+	return values
+}
+
+// Clamp a quantized level to the range [0, maxValue].
+func clampLevel(level, maxValue int) int {
+	if level < 0 {
+		return 0
+	}
+
+	if level > maxValue {
+		return maxValue
+	}
+
+	return level
+}
+
+// Create the thresholds of the Bayer matrix. The seed shifts where the matrix
+// starts so that different seeds give different, but repeatable, patterns.
+func makeBayerThresholds(seed int64) thresholdMatrix {
+	random := rand.New(rand.NewSource(seed))
+	offsetX := random.Intn(8)
+	offsetY := random.Intn(8)
+
+	thresholds := make([]float64, 64)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			rank := bayerMatrix[(y+offsetY)%8][(x+offsetX)%8]
+			thresholds[y*8+x] = (float64(rank) + 0.5) / 64
+		}
+	}
+
+	return thresholdMatrix{size: 8, thresholds: thresholds}
+}
+
+// Create the thresholds of a blue noise mask using the void-and-cluster
+// method. Pixels are ranked by repeatedly filling the largest gap in a binary
+// pattern, so pixels with similar thresholds are spread as far apart as
+// possible. The seed determines the random pattern the process starts from.
+func makeBlueNoiseThresholds(seed int64) thresholdMatrix {
+	size := blueNoiseSize
+	count := size * size
+	random := rand.New(rand.NewSource(seed))
+
+	// Precompute the Gaussian weight between pixels for every offset. Offsets
+	// wrap around the edges so that the mask tiles seamlessly.
+	kernel := make([]float64, count)
+	for dy := 0; dy < size; dy++ {
+		for dx := 0; dx < size; dx++ {
+			wrappedX := math.Min(float64(dx), float64(size-dx))
+			wrappedY := math.Min(float64(dy), float64(size-dy))
+			kernel[dy*size+dx] = math.Exp(-(wrappedX*wrappedX + wrappedY*wrappedY) / (2 * 1.5 * 1.5))
+		}
+	}
+
+	pattern := make([]bool, count)
+	energy := make([]float64, count)
+	toggle := func(index int) {
+		pattern[index] = !pattern[index]
+		sign := 1.0
+		if !pattern[index] {
+			sign = -1
+		}
+
+		x, y := index%size, index/size
+		for i := range energy {
+			dx := (i%size - x + size) % size
+			dy := (i/size - y + size) % size
+			energy[i] += sign * kernel[dy*size+dx]
+		}
+	}
+
+	// The tightest cluster is the set pixel with the most set neighbors, and
+	// the largest void is the unset pixel with the fewest.
+	tightestCluster := func() int {
+		best := -1
+		for i, set := range pattern {
+			if set && (best < 0 || energy[i] > energy[best]) {
+				best = i
+			}
+		}
+
+		return best
+	}
+	largestVoid := func() int {
+		best := -1
+		for i, set := range pattern {
+			if !set && (best < 0 || energy[i] < energy[best]) {
+				best = i
+			}
+		}
+
+		return best
+	}
+
+	// Start with a random pattern and move pixels from clusters to voids
+	// until the pattern is evenly distributed. The number of moves is capped
+	// in case the process settles into a cycle.
+	initial := count / 10
+	for _, index := range random.Perm(count)[:initial] {
+		toggle(index)
+	}
+
+	for moves := 0; moves < count; moves++ {
+		cluster := tightestCluster()
+		toggle(cluster)
+		void := largestVoid()
+		if void == cluster {
+			toggle(cluster)
+			break
+		}
+
+		toggle(void)
+	}
+
+	initialPattern := append([]bool(nil), pattern...)
+	initialEnergy := append([]float64(nil), energy...)
+	ranks := make([]int, count)
+
+	// Rank the pixels of the initial pattern by removing them from the
+	// tightest clusters first.
+	for rank := initial - 1; rank >= 0; rank-- {
+		cluster := tightestCluster()
+		ranks[cluster] = rank
+		toggle(cluster)
+	}
+
+	// Rank the remaining pixels by filling the largest voids first.
+	copy(pattern, initialPattern)
+	copy(energy, initialEnergy)
+	for rank := initial; rank < count; rank++ {
+		void := largestVoid()
+		ranks[void] = rank
+		toggle(void)
+	}
+
+	thresholds := make([]float64, count)
+	for i, rank := range ranks {
+		thresholds[i] = (float64(rank) + 0.5) / float64(count)
+	}
+
+	return thresholdMatrix{size: size, thresholds: thresholds}
+}
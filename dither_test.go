@@ -0,0 +1,184 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Create a canvas with a subtle horizontal gradient that bands when quantized
+// to a few levels.
+func makeGradientCanvas(width, height int) Canvas {
+	canvas := MakeCanvas(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := 0.3 + 0.2*float64(x)/float64(width)
+			canvas.SetPixel(x, y, MakeColor(value, value, value))
+		}
+	}
+
+	return canvas
+}
+
+func TestQuantizeCanvasValues_NoDither(t *testing.T) {
+	canvas := MakeCanvas(2, 1)
+	canvas.SetPixel(0, 0, MakeColor(0, 0.5, 1))
+	canvas.SetPixel(1, 0, MakeColor(-1, 0.2, 2))
+	want := []int{0, 128, 255, 0, 51, 255}
+
+	got := quantizeCanvasValues(canvas, MakeOutputTransform(), 255, Dither{})
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected value %d to be %d, got %d", i, want[i], got[i])
+		}
+	}
+}
+
+// With dithering, the average of the quantized values over each 8x8 block
+// should track the original gradient much more closely than plain rounding.
+func TestQuantizeCanvasValues_PreservesAverage(t *testing.T) {
+	canvas := makeGradientCanvas(64, 64)
+	maxValue := 3
+	blockSize := 8
+
+	blockError := func(values []int) float64 {
+		worst := 0.0
+		for blockY := 0; blockY < canvas.Height; blockY += blockSize {
+			for blockX := 0; blockX < canvas.Width; blockX += blockSize {
+				quantized, original := 0.0, 0.0
+				for y := blockY; y < blockY+blockSize; y++ {
+					for x := blockX; x < blockX+blockSize; x++ {
+						quantized += float64(values[(y*canvas.Width+x)*3]) / float64(maxValue)
+						original += canvas.GetPixel(x, y).Red()
+					}
+				}
+
+				worst = math.Max(worst, math.Abs(quantized-original)/float64(blockSize*blockSize))
+			}
+		}
+
+		return worst
+	}
+
+	undithered := blockError(quantizeCanvasValues(canvas, MakeOutputTransform(), maxValue, Dither{}))
+
+	for _, method := range []DitherMethod{DitherBayer, DitherBlueNoise, DitherFloydSteinberg} {
+		t.Run(method.String(), func(t *testing.T) {
+			values := quantizeCanvasValues(canvas, MakeOutputTransform(), maxValue, Dither{Method: method, Seed: 5})
+
+			if got := blockError(values); got > 0.05 || got >= undithered {
+				t.Errorf("Expected block error below %v and 0.05, got %v", undithered, got)
+			}
+		})
+	}
+}
+
+func TestQuantizeCanvasValues_Deterministic(t *testing.T) {
+	canvas := makeGradientCanvas(40, 40)
+
+	for _, method := range []DitherMethod{DitherBayer, DitherBlueNoise, DitherFloydSteinberg} {
+		t.Run(method.String(), func(t *testing.T) {
+			dither := Dither{Method: method, Seed: 11}
+			a := quantizeCanvasValues(canvas, MakeOutputTransform(), 255, dither)
+			b := quantizeCanvasValues(canvas, MakeOutputTransform(), 255, dither)
+
+			for i := range a {
+				if a[i] != b[i] {
+					t.Fatalf("Expected value %d to match for the same seed; got %d and %d", i, a[i], b[i])
+				}
+			}
+		})
+	}
+}
+
+func TestQuantizeCanvasValues_EmptyCanvas(t *testing.T) {
+	testCases := []struct {
+		name   string
+		width  int
+		height int
+	}{
+		{"empty", 0, 0},
+		{"no columns", 0, 3},
+		{"no rows", 3, 0},
+	}
+	for _, tt := range testCases {
+		for _, method := range []DitherMethod{DitherNone, DitherBayer, DitherBlueNoise, DitherFloydSteinberg} {
+			t.Run(tt.name+"/"+method.String(), func(t *testing.T) {
+				canvas := MakeCanvas(tt.width, tt.height)
+				values := quantizeCanvasValues(canvas, MakeOutputTransform(), 255, Dither{Method: method, Seed: 3})
+
+				if len(values) != 0 {
+					t.Errorf("Expected no values, got %d", len(values))
+				}
+			})
+		}
+	}
+}
+
+func TestMakeBlueNoiseThresholds(t *testing.T) {
+	matrix := makeBlueNoiseThresholds(1)
+	count := matrix.size * matrix.size
+
+	// Every rank should be used exactly once.
+	seen := make(map[int]bool)
+	for _, threshold := range matrix.thresholds {
+		rank := int(threshold*float64(count) - 0.5 + 0.25)
+		if seen[rank] {
+			t.Fatalf("Rank %d was used more than once", rank)
+		}
+
+		seen[rank] = true
+	}
+
+	if len(seen) != count {
+		t.Errorf("Expected %d distinct ranks, got %d", count, len(seen))
+	}
+
+	// The lowest thresholds should be spread out rather than clumped
+	// together, so no two of the first 5% should be direct neighbors.
+	limit := 0.05
+	for y := 0; y < matrix.size; y++ {
+		for x := 0; x < matrix.size; x++ {
+			if matrix.at(x, y) >= limit {
+				continue
+			}
+
+			for _, offset := range [][2]int{{1, 0}, {0, 1}, {1, 1}, {matrix.size - 1, 1}} {
+				if matrix.at(x+offset[0], y+offset[1]) < limit {
+					t.Errorf("Expected no neighboring low thresholds near (%d, %d)", x, y)
+				}
+			}
+		}
+	}
+}
+
+func TestMakeBlueNoiseThresholds_Seed(t *testing.T) {
+	a := makeBlueNoiseThresholds(1)
+	b := makeBlueNoiseThresholds(2)
+
+	same := true
+	for i := range a.thresholds {
+		if a.thresholds[i] != b.thresholds[i] {
+			same = false
+		}
+	}
+
+	if same {
+		t.Error("Expected different seeds to produce different masks")
+	}
+}
+
+func TestParseDitherMethod(t *testing.T) {
+	for _, method := range []DitherMethod{DitherNone, DitherBayer, DitherBlueNoise, DitherFloydSteinberg} {
+		t.Run(method.String(), func(t *testing.T) {
+			got, err := ParseDitherMethod(method.String())
+			if err != nil || got != method {
+				t.Errorf("Expected %v, got %v (error %v)", method, got, err)
+			}
+		})
+	}
+
+	if _, err := ParseDitherMethod("bogus"); err == nil {
+		t.Error("Expected error parsing unknown dithering method")
+	}
+}
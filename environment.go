@@ -0,0 +1,39 @@
+package main
+
+// An environment surrounds a world and determines the color seen by rays that
+// don't hit any objects.
+type Environment interface {
+	// Get the color seen when looking in a direction given in world space.
+	ColorInDirection(direction Tuple) Color
+}
+
+// A constant environment is the same color in every direction.
+type ConstantEnvironment struct {
+	Color Color
+}
+
+// Create an environment that is the same color in every direction.
+func MakeConstantEnvironment(color Color) ConstantEnvironment {
+	return ConstantEnvironment{Color: color}
+}
+
+// Get the color seen when looking in a direction.
+func (e ConstantEnvironment) ColorInDirection(direction Tuple) Color {
+	return e.Color
+}
+
+// A sampled environment is an environment that can choose random directions in
+// proportion to how much light arrives from them. The path integrator uses
+// sampled environments as light sources.
+type SampledEnvironment interface {
+	Environment
+
+	// Choose a random direction using two random values in the range [0, 1).
+	// The color seen in the direction and the probability density of choosing
+	// it per unit of solid angle are also returned. A density of zero means
+	// no direction could be chosen.
+	SampleDirection(u, v float64) (direction Tuple, color Color, pdf float64)
+
+	// Get the probability density of SampleDirection choosing a direction.
+	PDF(direction Tuple) float64
+}
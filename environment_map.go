@@ -0,0 +1,216 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// An image environment surrounds the world with an image, usually a high
+// dynamic range photograph of a real location. The image is an equirectangular
+// projection of every direction: the columns span the full circle around the y
+// axis and the rows span from straight up at the top to straight down at the
+// bottom. The image is laid out like a render with the equirectangular
+// projection from a camera at the origin with no transform, so the center of
+// the image is in the -z direction and -x is three quarters of the way across.
+//
+// Directions are sampled in proportion to the luminance of the image, so that
+// small, bright light sources like the sun are found quickly.
+type ImageEnvironment struct {
+	// The image of the surroundings.
+	Image Canvas
+	// The factor to scale the image's colors by.
+	Strength float64
+
+	// The cumulative distribution of choosing each row, and of choosing each
+	// column within each row.
+	rowCDF     []float64
+	columnCDFs [][]float64
+	// The probability density of choosing each pixel per unit of area of the
+	// image, where the whole image has an area of 1.
+	pixelPDFs []float64
+}
+
+// Create an environment from an equirectangular image.
+func MakeImageEnvironment(image Canvas) ImageEnvironment {
+	environment := ImageEnvironment{Image: image, Strength: 1}
+	width, height := image.Width, image.Height
+
+	// Each pixel is weighted by its luminance and the solid angle it covers,
+	// which shrinks towards the top and bottom of the image. Colors are
+	// interpolated between neighboring pixels, so the brightest neighbor is
+	// used to make sure the light that bleeds out of bright pixels is still
+	// sampled well.
+	weights := make([]float64, width*height)
+	rowWeights := make([]float64, height)
+	total := 0.0
+	for y := 0; y < height; y++ {
+		sinTheta := math.Sin((float64(y) + 0.5) / float64(height) * math.Pi)
+		for x := 0; x < width; x++ {
+			luminance := 0.0
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					neighborX := (x + dx + width) % width
+					neighborY := int(math.Max(0, math.Min(float64(height-1), float64(y+dy))))
+					luminance = math.Max(luminance, image.GetPixel(neighborX, neighborY).Luminance())
+				}
+			}
+
+			weight := luminance * sinTheta
+			weights[y*width+x] = weight
+			rowWeights[y] += weight
+		}
+		total += rowWeights[y]
+	}
+
+	environment.rowCDF = makeCDF(rowWeights)
+	environment.columnCDFs = make([][]float64, height)
+	for y := 0; y < height; y++ {
+		environment.columnCDFs[y] = makeCDF(weights[y*width : (y+1)*width])
+	}
+
+	environment.pixelPDFs = make([]float64, width*height)
+	if total > 0 {
+		for i, weight := range weights {
+			environment.pixelPDFs[i] = weight / total * float64(width*height)
+		}
+	}
+
+	return environment
+}
+
+// Get the color seen when looking in a direction. Colors are interpolated
+// between the centers of the nearest pixels.
+func (e ImageEnvironment) ColorInDirection(direction Tuple) Color {
+	u, v := equirectangularCoordinates(direction)
+	width, height := e.Image.Width, e.Image.Height
+
+	x := u*float64(width) - 0.5
+	y := v*float64(height) - 0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+
+	// The image wraps around horizontally and stops at the poles.
+	pixel := func(x, y int) Color {
+		x = ((x % width) + width) % width
+		y = int(math.Max(0, math.Min(float64(height-1), float64(y))))
+
+		return e.Image.GetPixel(x, y)
+	}
+
+	top := pixel(int(x0), int(y0)).Multiply(1 - fx).Add(pixel(int(x0)+1, int(y0)).Multiply(fx))
+	bottom := pixel(int(x0), int(y0)+1).Multiply(1 - fx).Add(pixel(int(x0)+1, int(y0)+1).Multiply(fx))
+
+	return top.Multiply(1 - fy).Add(bottom.Multiply(fy)).Multiply(e.Strength)
+}
+
+// Choose a random direction in proportion to the luminance of the image.
+func (e ImageEnvironment) SampleDirection(u, v float64) (Tuple, Color, float64) {
+	if len(e.rowCDF) == 0 {
+		return MakeVector(0, 1, 0), MakeColor(0, 0, 0), 0
+	}
+
+	y, offsetY := sampleCDF(e.rowCDF, u)
+	x, offsetX := sampleCDF(e.columnCDFs[y], v)
+
+	imageU := (float64(x) + offsetX) / float64(e.Image.Width)
+	imageV := (float64(y) + offsetY) / float64(e.Image.Height)
+	direction := equirectangularDirection(imageU, imageV)
+
+	sinTheta := math.Sin(imageV * math.Pi)
+	if sinTheta <= 0 {
+		return direction, MakeColor(0, 0, 0), 0
+	}
+
+	pdf := e.pixelPDFs[y*e.Image.Width+x] / (2 * math.Pi * math.Pi * sinTheta)
+
+	return direction, e.ColorInDirection(direction), pdf
+}
+
+// Get the probability density of SampleDirection choosing a direction.
+func (e ImageEnvironment) PDF(direction Tuple) float64 {
+	if len(e.pixelPDFs) == 0 {
+		return 0
+	}
+
+	u, v := equirectangularCoordinates(direction)
+	sinTheta := math.Sin(v * math.Pi)
+	if sinTheta <= 0 {
+		return 0
+	}
+
+	x := int(math.Min(float64(e.Image.Width-1), u*float64(e.Image.Width)))
+	y := int(math.Min(float64(e.Image.Height-1), v*float64(e.Image.Height)))
+
+	// Mapping the image onto the sphere stretches each unit of area by
+	// 2 pi^2 sin(theta).
+	return e.pixelPDFs[y*e.Image.Width+x] / (2 * math.Pi * math.Pi * sinTheta)
+}
+
+// Get the position of a direction in an equirectangular image, as fractions of
+// the image's width and height.
+func equirectangularCoordinates(direction Tuple) (float64, float64) {
+	direction = direction.Normalized()
+	longitude := math.Atan2(-direction.X, -direction.Z)
+	latitude := math.Asin(math.Max(-1, math.Min(1, direction.Y)))
+
+	u := 0.5 + longitude/(2*math.Pi)
+	v := 0.5 - latitude/math.Pi
+
+	return math.Mod(u, 1), v
+}
+
+// Get the direction at a position in an equirectangular image, given as
+// fractions of the image's width and height.
+func equirectangularDirection(u, v float64) Tuple {
+	longitude := (u - 0.5) * 2 * math.Pi
+	latitude := (0.5 - v) * math.Pi
+
+	return MakeVector(
+		-math.Sin(longitude)*math.Cos(latitude),
+		math.Sin(latitude),
+		-math.Cos(longitude)*math.Cos(latitude),
+	)
+}
+
+// Create the cumulative distribution of choosing each of a list of weights. The
+// distribution has one more entry than the weights, starting at 0 and ending at
+// the sum of the weights. Lists whose weights are all zero are chosen from
+// evenly instead.
+func makeCDF(weights []float64) []float64 {
+	total := 0.0
+	for _, weight := range weights {
+		total += weight
+	}
+
+	cdf := make([]float64, len(weights)+1)
+	for i, weight := range weights {
+		if total <= 0 {
+			weight = 1
+		}
+		cdf[i+1] = cdf[i] + weight
+	}
+
+	return cdf
+}
+
+// Choose an entry of a cumulative distribution with a random value in the
+// range [0, 1). The position of the value within the chosen entry is also
+// returned, as a fraction in the range [0, 1).
+func sampleCDF(cdf []float64, u float64) (int, float64) {
+	count := len(cdf) - 1
+	target := u * cdf[count]
+	index := sort.Search(count, func(i int) bool {
+		return cdf[i+1] > target
+	})
+	if index >= count {
+		index = count - 1
+	}
+
+	width := cdf[index+1] - cdf[index]
+	offset := 0.0
+	if width > 0 {
+		offset = math.Min(math.Nextafter(1, 0), (target-cdf[index])/width)
+	}
+
+	return index, offset
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Create an image whose top half is one color and bottom half is another, like
+// a sky over dark ground.
+func makeHorizonImage(width, height int, sky, ground Color) Canvas {
+	image := MakeCanvas(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			color := sky
+			if y >= height/2 {
+				color = ground
+			}
+			image.SetPixel(x, y, color)
+		}
+	}
+
+	return image
+}
+
+func TestEquirectangularCoordinates(t *testing.T) {
+	testCases := []struct {
+		name      string
+		direction Tuple
+		u, v      float64
+	}{
+		{"forward", MakeVector(0, 0, -1), 0.5, 0.5},
+		{"backward", MakeVector(0, 0, 1), 0, 0.5},
+		{"positive x", MakeVector(1, 0, 0), 0.25, 0.5},
+		{"negative x", MakeVector(-1, 0, 0), 0.75, 0.5},
+		{"up", MakeVector(0, 1, 0), 0.5, 0},
+		{"down", MakeVector(0, -1, 0), 0.5, 1},
+		{"unnormalized", MakeVector(0, 2, -2), 0.5, 0.25},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// Every longitude meets at the poles, so only the latitude is
+			// checked there.
+			pole := tt.v == 0 || tt.v == 1
+			u, v := equirectangularCoordinates(tt.direction)
+			if (!pole && !Float64Equal(u, tt.u)) || !Float64Equal(v, tt.v) {
+				t.Errorf("Expected coordinates (%v, %v), got (%v, %v)", tt.u, tt.v, u, v)
+			}
+
+			if !pole {
+				want := tt.direction.Normalized()
+				if got := equirectangularDirection(u, v); !want.Equals(got) {
+					t.Errorf("Expected direction %v, got %v", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestImageEnvironment_ColorInDirection(t *testing.T) {
+	image := MakeCanvas(4, 2)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			image.SetPixel(x, y, MakeColor(float64(x), float64(y), 1))
+		}
+	}
+	environment := MakeImageEnvironment(image)
+	environment.Strength = 2
+
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			direction := equirectangularDirection((float64(x)+0.5)/4, (float64(y)+0.5)/2)
+			want := image.GetPixel(x, y).Multiply(2)
+			if got := environment.ColorInDirection(direction); !want.Equals(got) {
+				t.Errorf("Expected the center of pixel (%v, %v) to be %v, got %v", x, y, want, got)
+			}
+		}
+	}
+
+	// Colors wrap around between the first and last columns.
+	direction := equirectangularDirection(0, 0.25)
+	want := MakeColor(1.5, 0, 1).Multiply(2)
+	if got := environment.ColorInDirection(direction); !want.Equals(got) {
+		t.Errorf("Expected the edge of the image to be %v, got %v", want, got)
+	}
+}
+
+// Rendering an environment with the equirectangular projection from the
+// origin reproduces its image.
+func TestImageEnvironment_ColorInDirection_Projection(t *testing.T) {
+	image := MakeCanvas(8, 4)
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			image.SetPixel(x, y, MakeColor(float64(x)/8, float64(y)/4, 0.5))
+		}
+	}
+	environment := MakeImageEnvironment(image)
+
+	camera := MakeCamera(8, 4, math.Pi/2)
+	camera.Projection = EquirectangularProjection{}
+
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			want := image.GetPixel(x, y)
+			if got := environment.ColorInDirection(camera.MakeRayForPixel(x, y).Direction); !want.Equals(got) {
+				t.Errorf("Expected pixel (%v, %v) to be %v, got %v", x, y, want, got)
+			}
+		}
+	}
+}
+
+func TestImageEnvironment_SampleDirection(t *testing.T) {
+	// A single bright pixel stands in for the sun.
+	image := MakeCanvas(16, 8)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			image.SetPixel(x, y, MakeColor(0.1, 0.1, 0.1))
+		}
+	}
+	image.SetPixel(5, 2, MakeColor(100, 100, 100))
+	environment := MakeImageEnvironment(image)
+
+	random := rand.New(rand.NewSource(0))
+	samples := 20000
+	inSun := 0
+	integral := 0.0
+	for i := 0; i < samples; i++ {
+		direction, color, pdf := environment.SampleDirection(random.Float64(), random.Float64())
+		if pdf <= 0 {
+			t.Fatalf("Expected a direction to be chosen")
+		}
+
+		if got := environment.PDF(direction); math.Abs(got-pdf) > 1e-5*pdf {
+			t.Fatalf("Expected the density of %v to be %v, got %v", direction, pdf, got)
+		}
+
+		u, v := equirectangularCoordinates(direction)
+		if math.Abs(math.Floor(u*16)-5) <= 1 && math.Abs(math.Floor(v*8)-2) <= 1 {
+			inSun++
+		}
+		integral += color.Red() / pdf
+	}
+
+	// The sun is much brighter than the rest of the sky, so most samples
+	// should find it or the pixels around it that its light bleeds into.
+	if fraction := float64(inSun) / float64(samples); fraction < 0.8 {
+		t.Errorf("Expected most samples to find the sun, got %v", fraction)
+	}
+
+	// Estimate the light arriving from all directions by sampling uniformly.
+	uniform := 0.0
+	for i := 0; i < samples*10; i++ {
+		direction := sampleUniformSphere(random.Float64(), random.Float64())
+		uniform += environment.ColorInDirection(direction).Red() * 4 * math.Pi
+	}
+	uniform /= float64(samples * 10)
+	integral /= float64(samples)
+
+	if math.Abs(integral-uniform) > 0.03*uniform {
+		t.Errorf("Expected importance sampling to estimate %v, got %v", uniform, integral)
+	}
+}
+
+func TestImageEnvironment_SampleDirection_Black(t *testing.T) {
+	environment := MakeImageEnvironment(MakeCanvas(4, 2))
+
+	if _, _, pdf := environment.SampleDirection(0.5, 0.5); pdf != 0 {
+		t.Errorf("Expected no direction to be chosen from a black image, got a density of %v", pdf)
+	}
+}
+
+// A white sphere in a uniformly white image environment reflects all of the
+// light reaching it, whichever way the light is found.
+func TestPathIntegrator_Radiance_ImageEnvironmentFurnace(t *testing.T) {
+	sphere := MakeSphere()
+	sphere.material.Diffuse = 1
+
+	world := MakeWorld()
+	world.Objects = []Object{sphere}
+	world.Environment = MakeImageEnvironment(makeHorizonImage(8, 4, MakeColor(1, 1, 1), MakeColor(1, 1, 1)))
+
+	random := rand.New(rand.NewSource(0))
+	samples := 5000
+	total := 0.0
+	for i := 0; i < samples; i++ {
+		ray := MakeRay(MakePoint(0, 0, -5), MakeVector(random.Float64()*0.2, random.Float64()*0.2, 1).Normalized())
+		total += MakePathIntegrator().Radiance(ray, world, 0, random).Red()
+	}
+
+	if average := total / float64(samples); math.Abs(average-1) > 0.02 {
+		t.Errorf("Expected all of the light to be reflected, got %v", average)
+	}
+}
+
+// The top of a diffuse sphere under a bright sky and black ground receives
+// light from the whole sky, whether or not the path continues after it.
+func TestPathIntegrator_Radiance_ImageEnvironmentSky(t *testing.T) {
+	sphere := MakeSphere()
+	sphere.material.Diffuse = 0.5
+
+	world := MakeWorld()
+	world.Objects = []Object{sphere}
+	world.Environment = MakeImageEnvironment(makeHorizonImage(16, 64, MakeColor(1, 1, 1), MakeColor(0, 0, 0)))
+
+	ray := MakeRay(MakePoint(0, 3, -2), MakeVector(0, -2, 2).Normalized())
+	for _, bounces := range []int{1, 2} {
+		random := rand.New(rand.NewSource(0))
+		samples := 5000
+		total := 0.0
+		for i := 0; i < samples; i++ {
+			total += PathIntegrator{MaxBounces: bounces}.Radiance(ray, world, 0, random).Red()
+		}
+
+		if average := total / float64(samples); math.Abs(average-0.5) > 0.01 {
+			t.Errorf("Expected a reflected color of 0.5 with %v bounces, got %v", bounces, average)
+		}
+	}
+}
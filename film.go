@@ -0,0 +1,100 @@
+package main
+
+import "math"
+
+// A film accumulates the samples taken while rendering. Each sample is spread
+// across the pixels within the radius of the film's filter, and the final
+// color of a pixel is the weighted average of every sample that reached it.
+type film struct {
+	width  int
+	height int
+	filter Filter
+
+	// Weighted sums of the samples that reached each pixel, stored in
+	// row-major order.
+	colors []Color
+	// Weighted sums of the alpha values of the samples that reached each
+	// pixel.
+	alphas []float64
+	// Sums of the weights of the samples that reached each pixel.
+	weights []float64
+}
+
+func makeFilm(width, height int, filter Filter) film {
+	return film{
+		width:   width,
+		height:  height,
+		filter:  filter,
+		colors:  make([]Color, width*height),
+		alphas:  make([]float64, width*height),
+		weights: make([]float64, width*height),
+	}
+}
+
+// Add an opaque sample taken at a continuous position on the film. The
+// position is given in pixel units, so the center of pixel (x, y) is at
+// (x + 0.5, y + 0.5).
+func (f *film) AddSample(x, y float64, color Color) {
+	f.AddSampleWithAlpha(x, y, color, 1)
+}
+
+// Add a sample with an alpha value taken at a continuous position on the film.
+// The color should be premultiplied by the alpha value.
+func (f *film) AddSampleWithAlpha(x, y float64, color Color, alpha float64) {
+	radius := f.filter.Radius()
+
+	// Find the range of pixels whose centers lie within the filter's radius.
+	minX := int(math.Max(0, math.Ceil(x-0.5-radius)))
+	maxX := int(math.Min(float64(f.width-1), math.Floor(x-0.5+radius)))
+	minY := int(math.Max(0, math.Ceil(y-0.5-radius)))
+	maxY := int(math.Min(float64(f.height-1), math.Floor(y-0.5+radius)))
+
+	for py := minY; py <= maxY; py++ {
+		for px := minX; px <= maxX; px++ {
+			weight := f.filter.Weight(x-(float64(px)+0.5), y-(float64(py)+0.5))
+			if weight == 0 {
+				continue
+			}
+
+			index := py*f.width + px
+			f.colors[index] = f.colors[index].Add(color.Multiply(weight))
+			f.alphas[index] += alpha * weight
+			f.weights[index] += weight
+		}
+	}
+}
+
+// Get the reconstructed color of a pixel. Pixels that have not received any
+// samples are black.
+func (f film) Pixel(x, y int) Color {
+	index := y*f.width + x
+	if f.weights[index] == 0 {
+		return MakeColor(0, 0, 0)
+	}
+
+	return f.colors[index].Multiply(1 / f.weights[index])
+}
+
+// Get the reconstructed alpha value of a pixel. Pixels that have not received
+// any samples are transparent.
+func (f film) Alpha(x, y int) float64 {
+	index := y*f.width + x
+	if f.weights[index] == 0 {
+		return 0
+	}
+
+	return f.alphas[index] / f.weights[index]
+}
+
+// Resolve the film into a canvas.
+func (f film) Canvas() Canvas {
+	canvas := MakeCanvas(f.width, f.height)
+	for y := 0; y < f.height; y++ {
+		for x := 0; x < f.width; x++ {
+			canvas.SetPixel(x, y, f.Pixel(x, y))
+			canvas.SetAlpha(x, y, f.Alpha(x, y))
+		}
+	}
+
+	return canvas
+}
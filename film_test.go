@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestFilm_AddSample_Box(t *testing.T) {
+	image := makeFilm(2, 1, MakeBoxFilter())
+	image.AddSample(0.25, 0.5, MakeColor(1, 0, 0))
+	image.AddSample(0.75, 0.5, MakeColor(0, 0, 1))
+	image.AddSample(1.5, 0.5, MakeColor(0, 1, 0))
+
+	if want, got := MakeColor(0.5, 0, 0.5), image.Pixel(0, 0); !want.Equals(got) {
+		t.Errorf("Expected pixel (0, 0) to be %v, got %v", want, got)
+	}
+
+	if want, got := MakeColor(0, 1, 0), image.Pixel(1, 0); !want.Equals(got) {
+		t.Errorf("Expected pixel (1, 0) to be %v, got %v", want, got)
+	}
+}
+
+// A tent filter spreads samples into neighboring pixels.
+func TestFilm_AddSample_Tent(t *testing.T) {
+	image := makeFilm(2, 1, MakeTentFilter())
+	image.AddSample(0.5, 0.5, MakeColor(1, 1, 1))
+	image.AddSample(1.5, 0.5, MakeColor(0, 0, 0))
+	image.AddSample(1, 0.5, MakeColor(1, 1, 1))
+
+	// Pixel 0 gets the white sample at its center with weight 1 and the white
+	// sample on the boundary with weight 0.5.
+	if want, got := MakeColor(1, 1, 1), image.Pixel(0, 0); !want.Equals(got) {
+		t.Errorf("Expected pixel (0, 0) to be %v, got %v", want, got)
+	}
+
+	// Pixel 1 gets the black sample at its center with weight 1 and the white
+	// sample on the boundary with weight 0.5.
+	if want, got := MakeColor(1.0/3, 1.0/3, 1.0/3), image.Pixel(1, 0); !want.Equals(got) {
+		t.Errorf("Expected pixel (1, 0) to be %v, got %v", want, got)
+	}
+}
+
+func TestFilm_Pixel_NoSamples(t *testing.T) {
+	image := makeFilm(1, 1, MakeBoxFilter())
+
+	if want, got := MakeColor(0, 0, 0), image.Pixel(0, 0); !want.Equals(got) {
+		t.Errorf("Expected empty pixel to be %v, got %v", want, got)
+	}
+}
+
+func TestFilm_AddSampleWithAlpha(t *testing.T) {
+	image := makeFilm(2, 1, MakeBoxFilter())
+	image.AddSampleWithAlpha(0.25, 0.5, MakeColor(1, 0, 0), 1)
+	image.AddSampleWithAlpha(0.75, 0.5, MakeColor(0, 0, 0), 0)
+
+	canvas := image.Canvas()
+
+	if want, got := MakeColor(0.5, 0, 0), canvas.GetPixel(0, 0); !want.Equals(got) {
+		t.Errorf("Expected pixel (0, 0) to be %v, got %v", want, got)
+	}
+
+	if got := canvas.GetAlpha(0, 0); !Float64Equal(0.5, got) {
+		t.Errorf("Expected pixel (0, 0) to have alpha 0.5, got %v", got)
+	}
+
+	// Pixels without samples are transparent.
+	if got := canvas.GetAlpha(1, 0); got != 0 {
+		t.Errorf("Expected pixel (1, 0) to have alpha 0, got %v", got)
+	}
+}
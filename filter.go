@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// A reconstruction filter determines how much a sample contributes to the
+// pixels around it.
+type Filter interface {
+	// Get the distance in pixels past which a sample has no influence.
+	Radius() float64
+
+	// Get the weight of a sample that is offset from a pixel's center by the
+	// given distances, specified in pixel units.
+	Weight(dx, dy float64) float64
+}
+
+// A box filter weighs every sample within its radius equally.
+type BoxFilter struct {
+	radius float64
+}
+
+// Create a box filter that only includes samples within the pixel itself.
+func MakeBoxFilter() BoxFilter {
+	return BoxFilter{radius: 0.5}
+}
+
+// Get the radius of the filter.
+func (f BoxFilter) Radius() float64 {
+	return f.radius
+}
+
+// Get the weight of a sample offset from a pixel's center.
+func (f BoxFilter) Weight(dx, dy float64) float64 {
+	if math.Abs(dx) > f.radius || math.Abs(dy) > f.radius {
+		return 0
+	}
+
+	return 1
+}
+
+// A tent filter weighs samples linearly less the further they are from the
+// pixel's center.
+type TentFilter struct {
+	radius float64
+}
+
+// Create a tent filter that reaches to the centers of the neighboring pixels.
+func MakeTentFilter() TentFilter {
+	return TentFilter{radius: 1}
+}
+
+// Get the radius of the filter.
+func (f TentFilter) Radius() float64 {
+	return f.radius
+}
+
+// Get the weight of a sample offset from a pixel's center.
+func (f TentFilter) Weight(dx, dy float64) float64 {
+	return math.Max(0, f.radius-math.Abs(dx)) * math.Max(0, f.radius-math.Abs(dy))
+}
+
+// A Gaussian filter weighs samples according to a Gaussian bell curve that has
+// been shifted so that it falls to zero at the filter's radius.
+type GaussianFilter struct {
+	radius float64
+	// The falloff rate of the curve. Larger values produce sharper images.
+	alpha float64
+}
+
+// Create a Gaussian filter with a moderate falloff.
+func MakeGaussianFilter() GaussianFilter {
+	return GaussianFilter{radius: 1.5, alpha: 2}
+}
+
+// Get the radius of the filter.
+func (f GaussianFilter) Radius() float64 {
+	return f.radius
+}
+
+// Get the weight of a sample offset from a pixel's center.
+func (f GaussianFilter) Weight(dx, dy float64) float64 {
+	return f.gaussian(dx) * f.gaussian(dy)
+}
+
+func (f GaussianFilter) gaussian(d float64) float64 {
+	edge := math.Exp(-f.alpha * f.radius * f.radius)
+
+	return math.Max(0, math.Exp(-f.alpha*d*d)-edge)
+}
+
+// A Mitchell-Netravali filter is a cubic filter that balances blurring against
+// ringing. Its negative lobes sharpen edges slightly.
+type MitchellFilter struct {
+	radius float64
+	b      float64
+	c      float64
+}
+
+// Create a Mitchell filter using the B = C = 1/3 parameters recommended by
+// Mitchell and Netravali.
+func MakeMitchellFilter() MitchellFilter {
+	return MitchellFilter{radius: 2, b: 1.0 / 3, c: 1.0 / 3}
+}
+
+// Get the radius of the filter.
+func (f MitchellFilter) Radius() float64 {
+	return f.radius
+}
+
+// Get the weight of a sample offset from a pixel's center.
+func (f MitchellFilter) Weight(dx, dy float64) float64 {
+	return f.mitchell(dx/f.radius) * f.mitchell(dy/f.radius)
+}
+
+// Evaluate the one dimensional Mitchell curve for a distance that has been
+// normalized to the range [-1, 1].
+func (f MitchellFilter) mitchell(d float64) float64 {
+	x := math.Abs(2 * d)
+	b, c := f.b, f.c
+
+	if x >= 2 {
+		return 0
+	}
+
+	if x >= 1 {
+		return ((-b-6*c)*x*x*x +
+			(6*b+30*c)*x*x +
+			(-12*b-48*c)*x +
+			(8*b + 24*c)) / 6
+	}
+
+	return ((12-9*b-6*c)*x*x*x +
+		(-18+12*b+6*c)*x*x +
+		(6 - 2*b)) / 6
+}
+
+// Get a filter by its name as it would be given on the command line.
+func ParseFilter(name string) (Filter, error) {
+	switch name {
+	case "box":
+		return MakeBoxFilter(), nil
+	case "tent":
+		return MakeTentFilter(), nil
+	case "gaussian":
+		return MakeGaussianFilter(), nil
+	case "mitchell":
+		return MakeMitchellFilter(), nil
+	}
+
+	return nil, fmt.Errorf("unknown filter '%s'", name)
+}
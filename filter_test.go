@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestFilter_Weight(t *testing.T) {
+	testCases := []struct {
+		name   string
+		filter Filter
+		dx     float64
+		dy     float64
+		want   float64
+	}{
+		{"box center", MakeBoxFilter(), 0, 0, 1},
+		{"box inside", MakeBoxFilter(), 0.4, -0.3, 1},
+		{"box outside", MakeBoxFilter(), 0.6, 0, 0},
+		{"tent center", MakeTentFilter(), 0, 0, 1},
+		{"tent halfway", MakeTentFilter(), 0.5, 0, 0.5},
+		{"tent diagonal", MakeTentFilter(), 0.5, 0.5, 0.25},
+		{"tent edge", MakeTentFilter(), 1, 0, 0},
+		{"gaussian edge", MakeGaussianFilter(), 1.5, 0, 0},
+		{"mitchell center", MakeMitchellFilter(), 0, 0, 0.8888889 * 0.8888889},
+		{"mitchell edge", MakeMitchellFilter(), 2, 0, 0},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Weight(tt.dx, tt.dy); !Float64Equal(tt.want, got) {
+				t.Errorf("Expected weight(%v, %v) = %v, got %v", tt.dx, tt.dy, tt.want, got)
+			}
+		})
+	}
+}
+
+// The Gaussian filter should fall off monotonically from its center.
+func TestGaussianFilter_Falloff(t *testing.T) {
+	filter := MakeGaussianFilter()
+
+	previous := filter.Weight(0, 0)
+	for _, d := range []float64{0.25, 0.5, 0.75, 1, 1.25} {
+		weight := filter.Weight(d, 0)
+		if weight >= previous {
+			t.Errorf("Expected weight at %v to be less than %v, got %v", d, previous, weight)
+		}
+
+		previous = weight
+	}
+}
+
+// The Mitchell filter has negative lobes past one pixel from the center.
+func TestMitchellFilter_NegativeLobe(t *testing.T) {
+	if got := MakeMitchellFilter().Weight(1.5, 0); got >= 0 {
+		t.Errorf("Expected negative weight, got %v", got)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	for _, name := range []string{"box", "tent", "gaussian", "mitchell"} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseFilter(name); err != nil {
+				t.Errorf("Expected filter '%s' to parse, got error %v", name, err)
+			}
+		})
+	}
+
+	if _, err := ParseFilter("bogus"); err == nil {
+		t.Error("Expected error parsing unknown filter")
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "regenerate the reference images used by golden image tests")
+
+// The largest difference in any color channel that is tolerated between a
+// render and its reference image.
+const goldenTolerance = 1.0 / 255
+
+// Compare a canvas to the reference image with the given name in
+// testdata/golden. The canvas is round-tripped through the same 16-bit PPM
+// encoding used for the reference so that clamping and quantization affect
+// both equally. Running the tests with -update rewrites the reference images
+// instead. When a canvas doesn't match, the render and a heatmap of the
+// differences are written to a temporary directory for inspection.
+func assertMatchesGolden(t *testing.T, name string, canvas Canvas) {
+	t.Helper()
+
+	path := filepath.Join("testdata", "golden", name+".ppm")
+
+	options := MakePPMOptions()
+	options.Format = PPMBinary
+	options.MaxColorValue = PPMLimitColorValue
+
+	var encoded bytes.Buffer
+	if err := WriteCanvasToPPMWithOptions(canvas, &encoded, options); err != nil {
+		t.Fatalf("Failed to encode render: %v", err)
+	}
+
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create golden image directory: %v", err)
+		}
+
+		if err := ioutil.WriteFile(path, encoded.Bytes(), 0644); err != nil {
+			t.Fatalf("Failed to write golden image: %v", err)
+		}
+
+		return
+	}
+
+	actual, err := ReadPPM(bytes.NewReader(encoded.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to decode render: %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open golden image; run the tests with -update to create it: %v", err)
+	}
+	defer file.Close()
+
+	expected, err := ReadPPM(file)
+	if err != nil {
+		t.Fatalf("Failed to read golden image '%s': %v", path, err)
+	}
+
+	difference, err := CompareCanvases(expected, actual, goldenTolerance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if difference.Matches() {
+		return
+	}
+
+	t.Errorf(
+		"Render differs from '%s' in %d pixels (RMSE %.6f, max error %.6f)",
+		path, difference.DifferingPixels, difference.RMSE, difference.MaxError,
+	)
+
+	dir, err := ioutil.TempDir("", "golden-"+name)
+	if err != nil {
+		t.Logf("Failed to create directory for the failed render: %v", err)
+		return
+	}
+
+	failures := map[string][]byte{name + ".ppm": encoded.Bytes()}
+	var heatmap bytes.Buffer
+	if err := WriteCanvasToPNG(difference.Heatmap, &heatmap); err == nil {
+		failures[name+".diff.png"] = heatmap.Bytes()
+	}
+
+	for fileName, contents := range failures {
+		if err := ioutil.WriteFile(filepath.Join(dir, fileName), contents, 0644); err != nil {
+			t.Logf("Failed to write '%s': %v", fileName, err)
+		}
+	}
+	t.Logf("Wrote the render and a heatmap of the differences to '%s'", dir)
+}
+
+// Renders of canonical scenes, checked against reference images to catch
+// unintended changes to the renderer's output.
+func TestRender_Golden(t *testing.T) {
+	makeCamera := func() Camera {
+		camera := MakeCamera(32, 32, math.Pi/2)
+		camera.Transform = ViewTransform(
+			MakePoint(0, 0, -5),
+			MakePoint(0, 0, 0),
+			MakeVector(0, 1, 0),
+		)
+
+		return camera
+	}
+
+	testCases := []struct {
+		name    string
+		camera  func() Camera
+		options func() RenderOptions
+	}{
+		{
+			"default-world",
+			makeCamera,
+			MakeRenderOptions,
+		},
+		{
+			"supersampled",
+			makeCamera,
+			func() RenderOptions {
+				options := MakeRenderOptions()
+				options.SamplesPerPixel = 4
+				options.SamplePattern = SampleJittered
+				options.Filter = MakeMitchellFilter()
+				options.Seed = 1
+				return options
+			},
+		},
+		{
+			"adaptive",
+			makeCamera,
+			func() RenderOptions {
+				options := MakeRenderOptions()
+				options.Adaptive = true
+				return options
+			},
+		},
+		{
+			"fisheye",
+			func() Camera {
+				camera := makeCamera()
+				camera.Projection = FisheyeProjection{}
+				camera.FieldOfView = math.Pi
+				return camera
+			},
+			MakeRenderOptions,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			canvas := RenderWithOptions(tt.camera(), MakeDefaultWorld(), tt.options()).Canvas
+
+			assertMatchesGolden(t, tt.name, canvas)
+		})
+	}
+}
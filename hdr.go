@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	// The first line of a Radiance HDR file.
+	HDRSignature = "#?RADIANCE"
+	// The pixel format of Radiance HDR files using RGB primaries.
+	HDRFormat = "32-bit_rle_rgbe"
+
+	// Scanlines may only be run length encoded if their width falls in this
+	// range.
+	hdrMinEncodedWidth = 8
+	hdrMaxEncodedWidth = 0x7fff
+	// The shortest sequence of repeated bytes worth encoding as a run.
+	hdrMinRunLength = 4
+)
+
+// Write the contents of a canvas as a Radiance HDR image. Each pixel is stored
+// as a shared exponent with an 8-bit mantissa per channel (RGBE), so
+// intensities above 1 are preserved. Negative intensities cannot be
+// represented and are written as 0. Scanlines are run length encoded.
+func WriteCanvasToHDR(canvas Canvas, dest io.Writer) error {
+	header := HDRSignature + "\n" +
+		"FORMAT=" + HDRFormat + "\n" +
+		"\n" +
+		fmt.Sprintf("-Y %d +X %d\n", canvas.Height, canvas.Width)
+
+	if _, err := dest.Write([]byte(header)); err != nil {
+		return fmt.Errorf("failed to write HDR header: %w", err)
+	}
+
+	scanline := make([][4]byte, canvas.Width)
+	for y := 0; y < canvas.Height; y++ {
+		for x := range scanline {
+			scanline[x] = colorToRGBE(canvas.GetPixel(x, y))
+		}
+
+		if err := writeHDRScanline(scanline, dest); err != nil {
+			return fmt.Errorf("failed to write HDR scanline %d: %w", y, err)
+		}
+	}
+
+	return nil
+}
+
+// Write a single scanline of RGBE pixels. Scanlines that are too short or too
+// long to be encoded are written as raw pixels.
+func writeHDRScanline(scanline [][4]byte, dest io.Writer) error {
+	width := len(scanline)
+	if width < hdrMinEncodedWidth || width > hdrMaxEncodedWidth {
+		raw := make([]byte, 0, width*4)
+		for _, pixel := range scanline {
+			raw = append(raw, pixel[:]...)
+		}
+
+		_, err := dest.Write(raw)
+
+		return err
+	}
+
+	// Encoded scanlines start with a marker that cannot be a valid pixel
+	// followed by the width of the scanline. Each channel is then encoded
+	// separately.
+	encoded := []byte{2, 2, byte(width >> 8), byte(width & 0xff)}
+	channel := make([]byte, width)
+	for i := 0; i < 4; i++ {
+		for x, pixel := range scanline {
+			channel[x] = pixel[i]
+		}
+
+		encoded = appendHDRRuns(encoded, channel)
+	}
+
+	_, err := dest.Write(encoded)
+
+	return err
+}
+
+// Append the run length encoding of a channel's data to a buffer. Runs of a
+// repeated byte are written as a count above 128 followed by the byte, and
+// other data is written as a count of up to 128 followed by that many bytes.
+func appendHDRRuns(encoded []byte, data []byte) []byte {
+	current := 0
+	for current < len(data) {
+		// Find the start of the next run that is long enough to encode.
+		runStart := current
+		runLength := 0
+		previousRunLength := 0
+		for runLength < hdrMinRunLength && runStart < len(data) {
+			runStart += runLength
+			previousRunLength = runLength
+			runLength = 1
+			for runStart+runLength < len(data) &&
+				runLength < 127 &&
+				data[runStart] == data[runStart+runLength] {
+				runLength++
+			}
+		}
+
+		// If the data before the run is itself a short run, it is cheaper to
+		// encode it as a run.
+		if previousRunLength > 1 && previousRunLength == runStart-current {
+			encoded = append(encoded, byte(128+previousRunLength), data[current])
+			current = runStart
+		}
+
+		// Write the data leading up to the run as is.
+		for current < runStart {
+			count := runStart - current
+			if count > 128 {
+				count = 128
+			}
+
+			encoded = append(encoded, byte(count))
+			encoded = append(encoded, data[current:current+count]...)
+			current += count
+		}
+
+		if runLength >= hdrMinRunLength {
+			encoded = append(encoded, byte(128+runLength), data[runStart])
+			current += runLength
+		}
+	}
+
+	return encoded
+}
+
+// Read a Radiance HDR image into a canvas. Both run length encoded and raw
+// scanlines are supported, but the image must use RGB primaries and the
+// standard top to bottom, left to right orientation.
+func ReadHDR(source io.Reader) (Canvas, error) {
+	reader := bufio.NewReader(source)
+
+	signature, err := readHDRLine(reader)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("failed to read HDR signature: %w", err)
+	}
+
+	if signature != HDRSignature && signature != "#?RGBE" {
+		return Canvas{}, fmt.Errorf("unsupported HDR signature '%s'", signature)
+	}
+
+	// The header is a list of variables terminated by an empty line.
+	for {
+		line, err := readHDRLine(reader)
+		if err != nil {
+			return Canvas{}, fmt.Errorf("failed to read HDR header: %w", err)
+		}
+
+		if line == "" {
+			break
+		}
+
+		if strings.HasPrefix(line, "FORMAT=") && line != "FORMAT="+HDRFormat {
+			return Canvas{}, fmt.Errorf("unsupported HDR format '%s'", strings.TrimPrefix(line, "FORMAT="))
+		}
+	}
+
+	resolution, err := readHDRLine(reader)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("failed to read HDR resolution: %w", err)
+	}
+
+	var width, height int
+	if _, err := fmt.Sscanf(resolution, "-Y %d +X %d", &height, &width); err != nil {
+		return Canvas{}, fmt.Errorf("unsupported HDR resolution '%s'", resolution)
+	}
+
+	if width < 1 || height < 1 {
+		return Canvas{}, fmt.Errorf("invalid HDR dimensions %dx%d", width, height)
+	}
+
+	canvas := MakeCanvas(width, height)
+	scanline := make([][4]byte, width)
+	for y := 0; y < height; y++ {
+		if err := readHDRScanline(reader, scanline); err != nil {
+			return Canvas{}, fmt.Errorf("failed to read HDR scanline %d: %w", y, err)
+		}
+
+		for x, pixel := range scanline {
+			canvas.SetPixel(x, y, rgbeToColor(pixel))
+		}
+	}
+
+	return canvas, nil
+}
+
+// Read a single line of an HDR header without its line ending.
+func readHDRLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Read a scanline of RGBE pixels that is either run length encoded or raw.
+func readHDRScanline(reader *bufio.Reader, scanline [][4]byte) error {
+	width := len(scanline)
+
+	var start [4]byte
+	if _, err := io.ReadFull(reader, start[:]); err != nil {
+		return err
+	}
+
+	encoded := start[0] == 2 && start[1] == 2 && start[2]&0x80 == 0
+	if !encoded {
+		// The bytes read so far are the first pixel of a raw scanline.
+		scanline[0] = start
+		for x := 1; x < width; x++ {
+			if _, err := io.ReadFull(reader, scanline[x][:]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if encodedWidth := int(start[2])<<8 | int(start[3]); encodedWidth != width {
+		return fmt.Errorf("scanline width %d does not match image width %d", encodedWidth, width)
+	}
+
+	for i := 0; i < 4; i++ {
+		for x := 0; x < width; {
+			count, err := reader.ReadByte()
+			if err != nil {
+				return err
+			}
+
+			isRun := count > 128
+			length := int(count)
+			if isRun {
+				length -= 128
+			}
+
+			if length == 0 || x+length > width {
+				return fmt.Errorf("invalid run length %d at x = %d", length, x)
+			}
+
+			if isRun {
+				value, err := reader.ReadByte()
+				if err != nil {
+					return err
+				}
+
+				for end := x + length; x < end; x++ {
+					scanline[x][i] = value
+				}
+
+				continue
+			}
+
+			for end := x + length; x < end; x++ {
+				if scanline[x][i], err = reader.ReadByte(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// Convert a color to a pixel with a shared exponent. The exponent is chosen so
+// that the brightest channel has a mantissa in the range [128, 256).
+func colorToRGBE(color Color) [4]byte {
+	red := math.Max(0, color.Red())
+	green := math.Max(0, color.Green())
+	blue := math.Max(0, color.Blue())
+
+	brightest := math.Max(red, math.Max(green, blue))
+	if brightest < 1e-32 {
+		return [4]byte{}
+	}
+
+	mantissa, exponent := math.Frexp(brightest)
+	scale := mantissa * 256 / brightest
+
+	return [4]byte{
+		byte(red * scale),
+		byte(green * scale),
+		byte(blue * scale),
+		byte(exponent + 128),
+	}
+}
+
+// Convert a pixel with a shared exponent back to a color.
+func rgbeToColor(pixel [4]byte) Color {
+	if pixel[3] == 0 {
+		return MakeColor(0, 0, 0)
+	}
+
+	scale := math.Ldexp(1, int(pixel[3])-(128+8))
+
+	return MakeColor(
+		(float64(pixel[0])+0.5)*scale,
+		(float64(pixel[1])+0.5)*scale,
+		(float64(pixel[2])+0.5)*scale,
+	)
+}
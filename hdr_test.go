@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestColorToRGBE(t *testing.T) {
+	testCases := []struct {
+		name  string
+		color Color
+		want  [4]byte
+	}{
+		{"black", MakeColor(0, 0, 0), [4]byte{0, 0, 0, 0}},
+		{"white", MakeColor(1, 1, 1), [4]byte{128, 128, 128, 129}},
+		{"bright", MakeColor(4, 2, 1), [4]byte{128, 64, 32, 131}},
+		{"negative clamped", MakeColor(-1, 0.5, 0), [4]byte{0, 128, 0, 128}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := colorToRGBE(tt.color); got != tt.want {
+				t.Errorf("Expected RGBE %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRGBEToColor(t *testing.T) {
+	if got := rgbeToColor([4]byte{0, 0, 0, 0}); !got.Equals(MakeColor(0, 0, 0)) {
+		t.Errorf("Expected black, got %v", got)
+	}
+
+	// Decoding uses the middle of the range each mantissa represents.
+	want := MakeColor(4+4.0/256, 2+4.0/256, 1+4.0/256)
+	if got := rgbeToColor([4]byte{128, 64, 32, 131}); !want.Equals(got) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestAppendHDRRuns(t *testing.T) {
+	testCases := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{
+			"no runs",
+			[]byte{1, 2, 3},
+			[]byte{3, 1, 2, 3},
+		},
+		{
+			"single run",
+			[]byte{7, 7, 7, 7, 7},
+			[]byte{128 + 5, 7},
+		},
+		{
+			"mixed",
+			[]byte{1, 2, 9, 9, 9, 9, 9, 9, 3},
+			[]byte{2, 1, 2, 128 + 6, 9, 1, 3},
+		},
+		{
+			"short run before long run",
+			[]byte{4, 4, 4, 5, 5, 5, 5},
+			[]byte{128 + 3, 4, 128 + 4, 5},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appendHDRRuns(nil, tt.data); !bytes.Equal(got, tt.want) {
+				t.Errorf("Expected encoding %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestReadHDR_RoundTrip(t *testing.T) {
+	testCases := []struct {
+		name  string
+		width int
+	}{
+		{"raw scanlines", 4},
+		{"encoded scanlines", 40},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			source := MakeCanvas(tt.width, 3)
+			for y := 0; y < source.Height; y++ {
+				for x := 0; x < source.Width; x++ {
+					// Use long runs of identical pixels along with a few
+					// unique ones.
+					value := float64(x/10) * 3.7
+					if x%7 == 0 {
+						value = float64(x*y) + 0.1
+					}
+
+					source.SetPixel(x, y, MakeColor(value, value/2, 50))
+				}
+			}
+
+			var buffer bytes.Buffer
+			if err := WriteCanvasToHDR(source, &buffer); err != nil {
+				t.Fatalf("WriteCanvasToHDR() returned error: %v", err)
+			}
+
+			got, err := ReadHDR(&buffer)
+			if err != nil {
+				t.Fatalf("ReadHDR() returned error: %v", err)
+			}
+
+			// The shared exponent limits the precision of every channel to
+			// the precision of the brightest channel.
+			for y := 0; y < source.Height; y++ {
+				for x := 0; x < source.Width; x++ {
+					want := source.GetPixel(x, y)
+					have := got.GetPixel(x, y)
+					tolerance := 50.0 / 128
+					diff := want.Subtract(have)
+					if math.Abs(diff.Red()) > tolerance || math.Abs(diff.Green()) > tolerance || math.Abs(diff.Blue()) > tolerance {
+						t.Errorf("Expected pixel (%d, %d) to be close to %v, got %v", x, y, want, have)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReadHDR_Errors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+	}{
+		{"bad signature", "#?NOTRADIANCE\n\n-Y 1 +X 1\n\x80\x80\x80\x81"},
+		{"xyz format", "#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n\x80\x80\x80\x81"},
+		{"flipped orientation", "#?RADIANCE\n\n+Y 1 +X 1\n\x80\x80\x80\x81"},
+		{"truncated", "#?RADIANCE\n\n-Y 1 +X 2\n\x80\x80\x80\x81"},
+		{"run too long", "#?RADIANCE\n\n-Y 1 +X 8\n\x02\x02\x00\x08\xff\x01"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadHDR(strings.NewReader(tt.contents)); err == nil {
+				t.Error("Expected ReadHDR() to return an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import "fmt"
+
+// A sampler provides the random numbers that integrators use to make random
+// choices, such as the direction a path of light bounces. A *rand.Rand is a
+// sampler.
+type Sampler interface {
+	// Get a number in the range [0, 1).
+	Float64() float64
+}
+
+// An integrator is a strategy for computing the light that travels along a
+// ray, which determines how a world is shaded.
+type Integrator interface {
+	// Compute the color seen along a ray. The depth is the number of times the
+	// ray has already bounced off of surfaces, which lets integrators limit
+	// how far they follow the light. Rays from the camera have a depth of
+	// zero.
+	Radiance(ray Ray, world World, depth int, sampler Sampler) Color
+}
+
+// A hit integrator can shade a ray from the camera whose nearest hit has
+// already been found, which saves finding it again when the renderer needs the
+// hit for other things too. The computations are ignored if hit is false,
+// meaning the ray missed every object. Renderers use it for the integrators
+// that support it and fall back to Radiance for the others.
+type hitIntegrator interface {
+	radianceForHit(ray Ray, world World, computation IntersectionComputation, hit bool, sampler Sampler) Color
+}
+
+// The Whitted integrator shades each hit with the Phong model using the
+// world's light source.
+type WhittedIntegrator struct {
+	// Ambient occlusion used to darken the ambient light in creases and
+	// corners. The ambient light is uniform if no occlusion samples are
+	// taken.
+	Occlusion AmbientOcclusion
+}
+
+// Compute the color seen along a ray.
+func (i WhittedIntegrator) Radiance(ray Ray, world World, depth int, sampler Sampler) Color {
+	computation, hit := world.primaryHit(ray)
+
+	return i.radianceForHit(ray, world, computation, hit, sampler)
+}
+
+// Compute the color seen along a ray from the camera with a known hit.
+func (i WhittedIntegrator) radianceForHit(ray Ray, world World, computation IntersectionComputation, hit bool, sampler Sampler) Color {
+	// No hit means we should return the color of the environment.
+	if !hit {
+		return world.environmentColor(ray)
+	}
+
+	if i.Occlusion.Samples < 1 {
+		return world.shadeHit(computation)
+	}
+
+	visibility := i.Occlusion.Visibility(world, computation, sampler)
+
+	return world.shadeHitWithAmbientOcclusion(computation, visibility)
+}
+
+// The normals integrator is a debugging aid that shows the surface normal of
+// each hit. The x, y, and z components of the normal are mapped from [-1, 1]
+// to the red, green, and blue channels.
+type NormalsIntegrator struct{}
+
+// Compute the color seen along a ray.
+func (i NormalsIntegrator) Radiance(ray Ray, world World, depth int, sampler Sampler) Color {
+	computation, hit := world.primaryHit(ray)
+
+	return i.radianceForHit(ray, world, computation, hit, sampler)
+}
+
+// Compute the color seen along a ray from the camera with a known hit.
+func (i NormalsIntegrator) radianceForHit(ray Ray, world World, computation IntersectionComputation, hit bool, sampler Sampler) Color {
+	if !hit {
+		return world.environmentColor(ray)
+	}
+
+	normal := computation.NormalVector
+
+	return MakeColor(normal.X+1, normal.Y+1, normal.Z+1).Multiply(0.5)
+}
+
+// Get an integrator by its name as it would be given on the command line.
+// Path tracing integrators follow up to eight bounces, and ambient occlusion
+// integrators use the default ambient occlusion settings.
+func ParseIntegrator(name string) (Integrator, error) {
+	switch name {
+	case "whitted":
+		return WhittedIntegrator{}, nil
+	case "path":
+		return MakePathIntegrator(), nil
+	case "ao":
+		return AmbientOcclusionIntegrator{Occlusion: MakeAmbientOcclusion()}, nil
+	case "normals":
+		return NormalsIntegrator{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown integrator '%s'", name)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseIntegrator(t *testing.T) {
+	testCases := []struct {
+		name    string
+		want    Integrator
+		wantErr bool
+	}{
+		{"whitted", WhittedIntegrator{}, false},
+		{"path", PathIntegrator{MaxBounces: 8}, false},
+		{"normals", NormalsIntegrator{}, false},
+		{"bogus", nil, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseIntegrator(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error = %v, got %v", tt.wantErr, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Expected integrator %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+// Shading a camera ray with its hit found ahead of time gives the same color as
+// finding the hit along the way.
+func TestHitIntegrator(t *testing.T) {
+	world := MakeDefaultWorld()
+	rays := []Ray{
+		MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1)),
+		MakeRay(MakePoint(0, 0, -5), MakeVector(0, 1, 0)),
+	}
+
+	testCases := []struct {
+		name       string
+		integrator Integrator
+	}{
+		{"whitted", WhittedIntegrator{}},
+		{"path", MakePathIntegrator()},
+		{"ao", AmbientOcclusionIntegrator{Occlusion: MakeAmbientOcclusion()}},
+		{"normals", NormalsIntegrator{}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, ray := range rays {
+				want := tt.integrator.Radiance(ray, world, 0, rand.New(rand.NewSource(3)))
+
+				integrator, ok := tt.integrator.(hitIntegrator)
+				if !ok {
+					t.Fatalf("Expected %T to shade known hits", tt.integrator)
+				}
+
+				computation, hit := world.primaryHit(ray)
+				got := integrator.radianceForHit(ray, world, computation, hit, rand.New(rand.NewSource(3)))
+
+				if !want.Equals(got) {
+					t.Errorf("Expected color %v along %v, got %v", want, ray, got)
+				}
+			}
+		})
+	}
+}
+
+// An integrator that only implements the Integrator interface.
+type constantIntegrator struct {
+	color Color
+}
+
+func (i constantIntegrator) Radiance(ray Ray, world World, depth int, sampler Sampler) Color {
+	return i.color
+}
+
+// Integrators that can't shade a known hit are asked for the radiance along
+// the ray instead.
+func TestTraceCameraRay_Integrator(t *testing.T) {
+	world := MakeDefaultWorld()
+	ray := MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1))
+	want := MakeColor(0.1, 0.2, 0.3)
+
+	options := MakeRenderOptions()
+	options.Integrator = constantIntegrator{want}
+	got, computation, hit := traceCameraRay(world, ray, options, rand.New(rand.NewSource(3)))
+
+	if !want.Equals(got) {
+		t.Errorf("Expected color %v, got %v", want, got)
+	}
+
+	if !hit || !Float64Equal(computation.T, 4) {
+		t.Errorf("Expected a hit at t = 4, got hit = %v at t = %v", hit, computation.T)
+	}
+}
+
+func TestWhittedIntegrator_Radiance(t *testing.T) {
+	world := MakeDefaultWorld()
+	world.Environment = MakeConstantEnvironment(MakeColor(0, 0, 1))
+
+	testCases := []struct {
+		name string
+		ray  Ray
+		want Color
+	}{
+		{
+			"hit",
+			MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1)),
+			MakeColor(0.38066, 0.47583, 0.2855),
+		},
+		{
+			"miss",
+			MakeRay(MakePoint(0, 0, -5), MakeVector(0, 1, 0)),
+			MakeColor(0, 0, 1),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (WhittedIntegrator{}).Radiance(tt.ray, world, 0, nil); !tt.want.Equals(got) {
+				t.Errorf("Expected color %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNormalsIntegrator_Radiance(t *testing.T) {
+	world := MakeDefaultWorld()
+	ray := MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1))
+
+	// The front of the sphere faces the camera along the negative z-axis.
+	want := MakeColor(0.5, 0.5, 0)
+	if got := (NormalsIntegrator{}).Radiance(ray, world, 0, nil); !want.Equals(got) {
+		t.Errorf("Expected color %v, got %v", want, got)
+	}
+}
+
+func TestRenderWithOptions_Integrator(t *testing.T) {
+	camera := MakeCamera(5, 5, 1)
+	camera.Transform = ViewTransform(
+		MakePoint(0, 0, -5),
+		MakePoint(0, 0, 0),
+		MakeVector(0, 1, 0),
+	)
+
+	options := MakeRenderOptions()
+	options.Integrator = NormalsIntegrator{}
+	image := RenderWithOptions(camera, MakeDefaultWorld(), options).Canvas
+
+	if want, got := MakeColor(0.5, 0.5, 0), image.GetPixel(2, 2); !want.Equals(got) {
+		t.Errorf("Expected center pixel to be %v, got %v", want, got)
+	}
+}
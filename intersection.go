@@ -5,10 +5,14 @@ import "sort"
 type Intersection struct {
 	T      float64
 	Object Object
+
+	// The index of the object within the world that was intersected. This is
+	// only set for intersections found by a world.
+	ObjectIndex int
 }
 
 func MakeIntersection(t float64, object Object) Intersection {
-	return Intersection{t, object}
+	return Intersection{T: t, Object: object}
 }
 
 // Prepare some useful properties about the intersection for later use.
@@ -16,21 +20,27 @@ func (i Intersection) PrepareComputations(ray Ray) IntersectionComputation {
 	intersectionPoint := ray.Position(i.T)
 	eyeVector := ray.Direction.Negate()
 	normalVector := i.Object.NormalAt(intersectionPoint)
+	shadingNormal := i.Object.Material().perturbNormal(i.Object, intersectionPoint, normalVector)
 
 	// The hit occurred on the inside of the shape if the eye vector and normal
 	// vector are pointing roughly in opposite directions.
 	inside := normalVector.Dot(eyeVector) < 0
 	if inside {
 		normalVector = normalVector.Negate()
+		shadingNormal = shadingNormal.Negate()
 	}
 
 	return IntersectionComputation{
 		T:            i.T,
+		Time:         ray.Time,
 		Object:       i.Object,
+		ObjectIndex:  i.ObjectIndex,
 		Inside:       inside,
 		Point:        intersectionPoint,
+		OverPoint:    intersectionPoint.Add(normalVector.Multiply(floatEpsilon)),
+		UnderPoint:   intersectionPoint.Subtract(normalVector.Multiply(floatEpsilon)),
 		EyeVector:    eyeVector,
-		NormalVector: normalVector,
+		NormalVector: shadingNormal,
 	}
 }
 
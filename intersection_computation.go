@@ -4,15 +4,52 @@ package main
 type IntersectionComputation struct {
 	// The t-value of the intersection that produced this computation.
 	T float64
+	// The time of the ray that produced this computation.
+	Time float64
 	// The object of the intersection that produced this computation.
 	Object Object
+	// The index of the intersected object within the world.
+	ObjectIndex int
 
 	// Boolean indicating if the hit occurred on the inside of the shape.
 	Inside bool
 	// The point where the intersection occurred.
 	Point Tuple
+	// The point where the intersection occurred nudged slightly along the
+	// normal vector. Rays leaving the surface start from this point so that
+	// floating point errors don't cause them to hit the surface they started
+	// on.
+	OverPoint Tuple
+	// The point where the intersection occurred nudged slightly against the
+	// normal vector. Rays passing through the surface start from this point.
+	UnderPoint Tuple
 	// A vector pointing from the intersection point back to the observer's eye.
 	EyeVector Tuple
 	// The normal vector of the intersected object at the point of intersection.
+	// Materials with normal or bump maps tilt it away from the true normal of
+	// the surface, which is still used to offset the over and under points.
 	NormalVector Tuple
 }
+
+// Get the material of the intersected object at the point of intersection.
+// Textured materials take their color from the texture at the point in the
+// object's space.
+func (c IntersectionComputation) material() Material {
+	material := c.Object.Material()
+	if material.Texture != nil {
+		objectPoint := c.Object.Transform().Inverted().TupleMultiply(c.Point)
+		material.Color = material.Texture.ColorAt(objectPoint)
+	}
+
+	return material
+}
+
+// Get the normal of the surface pointing out of the object, which is the
+// orientation BSDFs expect.
+func (c IntersectionComputation) surfaceNormal() Tuple {
+	if c.Inside {
+		return c.NormalVector.Negate()
+	}
+
+	return c.NormalVector
+}
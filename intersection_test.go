@@ -174,3 +174,56 @@ func TestIntersections_Sort(t *testing.T) {
 		})
 	}
 }
+
+// The over point should sit just above the surface so that rays leaving the
+// surface don't hit it again.
+func TestIntersection_PrepareComputations_OverPoint(t *testing.T) {
+	ray := MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1))
+	shape := MakeSphereTransformed(MakeTranslation(0, 0, 1))
+	comp := MakeIntersection(5, shape).PrepareComputations(ray)
+
+	if comp.OverPoint.Z >= -floatEpsilon/2 {
+		t.Errorf("Expected over point to be above the surface, got z = %v", comp.OverPoint.Z)
+	}
+
+	if comp.Point.Z <= comp.OverPoint.Z {
+		t.Errorf("Expected point z = %v to be below over point z = %v", comp.Point.Z, comp.OverPoint.Z)
+	}
+}
+
+// The under point should sit just below the surface so that rays passing
+// through the surface don't hit it again.
+// Textured materials take their color from the texture at the hit point in
+// object space.
+func TestIntersectionComputation_Material_Texture(t *testing.T) {
+	image := MakeCanvas(2, 1)
+	image.SetPixel(0, 0, MakeColor(1, 0, 0))
+	image.SetPixel(1, 0, MakeColor(0, 0, 1))
+
+	shape := MakeSphereTransformed(MakeTranslation(5, 0, 0).Multiply(MakeScale(2, 2, 2)))
+	shape.material.Texture = MakeImageTexture(image, PlanarMapping{})
+	shape.material.Texture.(*ImageTexture).Wrap = TextureWrapClamp
+
+	// The ray hits the sphere at x = 1 in object space, in the right half of
+	// the image.
+	ray := MakeRay(MakePoint(7, 10, 0), MakeVector(0, -1, 0))
+	got := MakeIntersection(10, shape).PrepareComputations(ray).material()
+
+	if want := MakeColor(0, 0, 1); !got.Color.Equals(want) {
+		t.Errorf("Expected color %v, got %v", want, got.Color)
+	}
+}
+
+func TestIntersection_PrepareComputations_UnderPoint(t *testing.T) {
+	ray := MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1))
+	shape := MakeSphereTransformed(MakeTranslation(0, 0, 1))
+	comp := MakeIntersection(5, shape).PrepareComputations(ray)
+
+	if comp.UnderPoint.Z <= floatEpsilon/2 {
+		t.Errorf("Expected under point to be below the surface, got z = %v", comp.UnderPoint.Z)
+	}
+
+	if comp.Point.Z >= comp.UnderPoint.Z {
+		t.Errorf("Expected point z = %v to be above under point z = %v", comp.Point.Z, comp.UnderPoint.Z)
+	}
+}
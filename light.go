@@ -14,9 +14,39 @@ func MakePointLight(position Tuple, intensity Color) PointLight {
 	}
 }
 
+// A directional light is infinitely far away, so its light arrives from the
+// same direction everywhere, like sunlight. Directional lights add no ambient
+// light.
+type DirectionalLight struct {
+	// The direction pointing towards the light.
+	Direction Tuple
+	Intensity Color
+}
+
+// Create a directional light shining from the given direction.
+func MakeDirectionalLight(direction Tuple, intensity Color) DirectionalLight {
+	return DirectionalLight{
+		Direction: direction.Normalized(),
+		Intensity: intensity,
+	}
+}
+
+// Determine if the light gives off any light. The zero value of a directional
+// light is off.
+func (l DirectionalLight) IsOn() bool {
+	return !l.Intensity.Equals(MakeColor(0, 0, 0))
+}
+
 // Get the color of a position given a material, light source, observer, and the
 // normal of the illuminated surface.
 func Lighting(material Material, light PointLight, position Tuple, eyeVector Tuple, normal Tuple) Color {
+	return LightingWithAmbientOcclusion(material, light, position, eyeVector, normal, 1)
+}
+
+// Get the color of a position like Lighting, with the ambient color scaled by
+// the fraction of ambient light that reaches the position. A visibility of 1
+// is the same as Lighting.
+func LightingWithAmbientOcclusion(material Material, light PointLight, position Tuple, eyeVector Tuple, normal Tuple, visibility float64) Color {
 	// Initial color is a combination of the material's color and the light's
 	// color.
 	effectiveColor := material.Color.Blend(light.Intensity)
@@ -25,7 +55,19 @@ func Lighting(material Material, light PointLight, position Tuple, eyeVector Tup
 
 	// The ambient color is the color contribution from "background" light or
 	// the color shown with no light sources.
-	ambient := effectiveColor.Multiply(material.Ambient)
+	ambient := effectiveColor.Multiply(material.Ambient * visibility)
+
+	return ambient.Add(reflectedLight(material, light.Intensity, lightVector, eyeVector, normal))
+}
+
+// Get the diffuse and specular light reflected towards the eye from a light
+// with the given intensity in the direction of the light vector.
+func reflectedLight(material Material, intensity Color, lightVector Tuple, eyeVector Tuple, normal Tuple) Color {
+	if material.BSDF != nil {
+		return bsdfLighting(material.BSDF, intensity, lightVector, eyeVector, normal)
+	}
+
+	effectiveColor := material.Color.Blend(intensity)
 
 	diffuse := MakeColor(0, 0, 0)
 	specular := MakeColor(0, 0, 0)
@@ -45,9 +87,21 @@ func Lighting(material Material, light PointLight, position Tuple, eyeVector Tup
 		reflectionDotEye := reflectionVector.Dot(eyeVector)
 		if reflectionDotEye > 0 {
 			factor := math.Pow(reflectionDotEye, material.Shininess)
-			specular = light.Intensity.Multiply(material.Specular).Multiply(factor)
+			specular = intensity.Multiply(material.Specular).Multiply(factor)
 		}
 	}
 
-	return ambient.Add(diffuse).Add(specular)
+	return diffuse.Add(specular)
+}
+
+// Get the light scattered towards the eye by a surface with a BSDF. The light
+// delivers the same irradiance as it does to Phong surfaces, so a white
+// Lambertian surface is lit like a Phong surface with a diffuse value of 1.
+func bsdfLighting(bsdf BSDF, intensity Color, lightVector, eyeVector, normal Tuple) Color {
+	cosine := lightVector.Dot(normal)
+	if cosine <= 0 {
+		return MakeColor(0, 0, 0)
+	}
+
+	return bsdf.Evaluate(normal, eyeVector, lightVector).Blend(intensity).Multiply(math.Pi * cosine)
 }
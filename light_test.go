@@ -20,6 +20,28 @@ func TestMakePointLight(t *testing.T) {
 	}
 }
 
+func TestMakeDirectionalLight(t *testing.T) {
+	intensity := MakeColor(1, 0.9, 0.8)
+
+	light := MakeDirectionalLight(MakeVector(0, 3, 4), intensity)
+
+	if want, got := MakeVector(0, 0.6, 0.8), light.Direction; !got.Equals(want) {
+		t.Errorf("Expected light direction to be %v, got %v", want, got)
+	}
+
+	if got := light.Intensity; !got.Equals(intensity) {
+		t.Errorf("Expected light intensity to be %v, got %v", intensity, got)
+	}
+
+	if !light.IsOn() {
+		t.Errorf("Expected light to be on")
+	}
+
+	if (DirectionalLight{}).IsOn() {
+		t.Errorf("Expected the zero directional light to be off")
+	}
+}
+
 func TestLighting(t *testing.T) {
 	testCases := []struct {
 		name      string
@@ -74,3 +96,80 @@ func TestLighting(t *testing.T) {
 		})
 	}
 }
+
+func TestLightingWithAmbientOcclusion(t *testing.T) {
+	material := MakeMaterial()
+	position := MakePoint(0, 0, 0)
+	eyeVector := MakeVector(0, 0, -1)
+	normal := MakeVector(0, 0, -1)
+	// The light is behind the surface, so only ambient light remains.
+	light := MakePointLight(MakePoint(0, 0, 10), MakeColor(1, 1, 1))
+
+	testCases := []struct {
+		visibility float64
+		want       Color
+	}{
+		{1, MakeColor(0.1, 0.1, 0.1)},
+		{0.5, MakeColor(0.05, 0.05, 0.05)},
+		{0, MakeColor(0, 0, 0)},
+	}
+	for _, tt := range testCases {
+		got := LightingWithAmbientOcclusion(material, light, position, eyeVector, normal, tt.visibility)
+		if !tt.want.Equals(got) {
+			t.Errorf("Expected color %v with visibility %v, got %v", tt.want, tt.visibility, got)
+		}
+	}
+}
+
+func TestLighting_Microfacet(t *testing.T) {
+	// A rough white metal with the light head on only reflects off of the
+	// facets facing the light, where the distribution is 1/pi, the facets
+	// are unshadowed, and the Fresnel reflectance is 1.
+	material := MakeMicrofacetMaterial(MakeColor(1, 1, 1), 1, 1)
+	position := MakePoint(0, 0, 0)
+	eyeVector := MakeVector(0, 0, -1)
+	normal := MakeVector(0, 0, -1)
+
+	testCases := []struct {
+		name  string
+		light PointLight
+		want  Color
+	}{
+		{
+			"light head on",
+			MakePointLight(MakePoint(0, 0, -10), MakeColor(1, 1, 1)),
+			MakeColor(0.35, 0.35, 0.35),
+		},
+		{
+			"light behind the surface",
+			MakePointLight(MakePoint(0, 0, 10), MakeColor(1, 1, 1)),
+			MakeColor(0.1, 0.1, 0.1),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lighting(material, tt.light, position, eyeVector, normal); !tt.want.Equals(got) {
+				t.Errorf("Expected color %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// A white Lambertian BSDF receives the same light as a Phong material that is
+// fully diffuse.
+func TestLighting_BSDF(t *testing.T) {
+	phong := MakeMaterial()
+	phong.Diffuse = 1
+	phong.Specular = 0
+	material := MakeBSDFMaterial(MakeColor(1, 1, 1), LambertianBSDF{Albedo: MakeColor(1, 1, 1)})
+
+	position := MakePoint(0, 0, 0)
+	eyeVector := MakeVector(0, 0, -1)
+	normal := MakeVector(0, 0, -1)
+	light := MakePointLight(MakePoint(0, 10, -10), MakeColor(1, 1, 1))
+
+	want := Lighting(phong, light, position, eyeVector, normal)
+	if got := Lighting(material, light, position, eyeVector, normal); !want.Equals(got) {
+		t.Errorf("Expected color %v, got %v", want, got)
+	}
+}
@@ -3,16 +3,84 @@ package main
 import (
 	"bufio"
 	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
 	"log"
 	"math"
+	"net"
+	"net/http"
 	"os"
+	"path/filepath"
 	"runtime/pprof"
+	"strconv"
+	"strings"
 )
 
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
+var outputPath = flag.String("output", "output.ppm", "file to write the image to; the format is picked from the extension (.ppm, .pam, .png, .jpg, .pfm, .hdr)")
+var samples = flag.Int("samples", 1, "number of samples to take for each pixel")
+var samplePattern = flag.String("pattern", "grid", "sample pattern: grid, jittered, or random")
+var filterName = flag.String("filter", "box", "reconstruction filter: box, tent, gaussian, or mitchell")
+var seed = flag.Int64("seed", 0, "seed for randomized sampling")
+var aperture = flag.Float64("aperture", 0, "radius of the camera's lens; zero renders everything in focus")
+var focalDistance = flag.Float64("focal-distance", 0, "distance to the plane in focus; defaults to the distance to the point the camera looks at")
+var projectionName = flag.String("projection", "perspective", "camera projection: perspective, orthographic, fisheye, or equirectangular")
+var orthographicSize = flag.Float64("ortho-size", 10, "size of the view's longer side in world units for orthographic projections")
+var shutter = flag.Float64("shutter", 0, "fraction of the animation the shutter stays open for, which blurs moving objects")
+var ppmBinary = flag.Bool("ppm-binary", false, "write the PPM's pixel data as raw bytes (P6) instead of text (P3)")
+var ppmMaxValue = flag.Int("ppm-max-value", PPMMaxColorValue, "maximum color value of PPM and PAM images; values above 255 write 16-bit color")
+var jpegQuality = flag.Int("jpeg-quality", JPEGDefaultQuality, "quality of JPEG output in the range [1, 100]")
+var exposure = flag.Float64("exposure", 0, "exposure adjustment in stops applied before writing 8-bit images")
+var toneMapName = flag.String("tonemap", "clamp", "tone mapping for 8-bit images: clamp, reinhard, extended-reinhard, or aces")
+var whitePoint = flag.Float64("white-point", 4, "intensity that maps to white with the extended-reinhard tone mapping")
+var srgb = flag.Bool("srgb", true, "encode 8-bit images with the sRGB transfer function")
+var ditherName = flag.String("dither", "none", "dithering for PPM and PNG output: none, bayer, blue-noise, or floyd-steinberg")
+var ditherSeed = flag.Int64("dither-seed", 0, "seed for the placement of ordered dithering patterns")
+var passNames = flag.String("passes", "", "comma separated render passes to write next to the image: depth, normal, albedo, object-id, or mask")
+var background = flag.String("background", "0,0,0", "color of the environment surrounding the scene as comma separated red, green, and blue intensities")
+var environmentPath = flag.String("environment", "", "equirectangular image (.hdr, .pfm, or any readable format) surrounding the scene and lighting it with the path integrator; replaces -background")
+var environmentStrength = flag.Float64("environment-strength", 1, "factor scaling the brightness of the -environment image")
+var sky = flag.Bool("sky", false, "surround the scene with a clear daytime sky lit by the sun instead of the point light; replaces -background")
+var sunElevation = flag.Float64("sun-elevation", 45, "angle of the sun above the horizon in degrees for -sky")
+var sunAzimuth = flag.Float64("sun-azimuth", 180, "angle of the sun around the vertical axis in degrees for -sky; zero is beyond the scene, 90 is to the right, and 180 is behind the camera")
+var turbidity = flag.Float64("turbidity", 3, "haziness of the atmosphere for -sky, from 2 for a very clear sky to 10 for a hazy one")
+var texturePath = flag.String("texture", "", "image (.ppm, .png, .jpg, .pfm, or .hdr) giving the color of the middle sphere")
+var textureMapping = flag.String("texture-mapping", "spherical", "how the -texture image is laid over the sphere: spherical, planar, cylindrical, or cube")
+var textureWrap = flag.String("texture-wrap", "repeat", "how the -texture, -normal-map, and -bump-map images extend beyond their edges: repeat or clamp")
+var normalMapPath = flag.String("normal-map", "", "tangent space normal map image adding surface detail to the middle sphere, laid over it with -texture-mapping")
+var bumpMapPath = flag.String("bump-map", "", "height image adding surface detail to the middle sphere, laid over it with -texture-mapping, or 'noise' for procedural bumps")
+var bumpHeight = flag.Float64("bump-height", 0.05, "height in world units of the white parts of the -bump-map")
+var transparent = flag.Bool("transparent", false, "render the background as transparent in formats with an alpha channel (PNG and PAM)")
+var regionFlag = flag.String("region", "", "only render the pixels in the rectangle 'x,y,width,height'")
+var crop = flag.Bool("crop", false, "write only the pixels in the region instead of a full-size image")
+var address = flag.String("addr", "localhost:8080", "address the preview server listens on when running the serve command")
+var integratorName = flag.String("integrator", "whitted", "shading strategy: whitted, path, ao, or normals; path tracing needs many samples per pixel")
+var ambientOcclusion = flag.Bool("ambient-occlusion", false, "darken the ambient light in creases and corners with the whitted integrator")
+var occlusionSamples = flag.Int("ao-samples", 16, "number of rays cast from each hit to estimate ambient occlusion")
+var occlusionDistance = flag.Float64("ao-distance", 2, "distance within which surfaces occlude each other; zero considers any distance")
+var maxBounces = flag.Int("max-bounces", 8, "maximum number of times a path bounces off of surfaces when path tracing")
+var adaptive = flag.Bool("adaptive", false, "only subdivide pixels with high contrast instead of supersampling every pixel")
+var adaptiveThreshold = flag.Float64("threshold", 0.1, "contrast that causes a pixel to be subdivided in adaptive mode")
+var adaptiveMaxDepth = flag.Int("max-depth", 3, "maximum number of times a pixel is subdivided in adaptive mode")
 
 func main() {
-	flag.Parse()
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		os.Exit(runCompare(os.Args[2:]))
+	}
+
+	// The serve command renders progressively while showing a preview over
+	// HTTP. It accepts the same flags as a normal render.
+	serve := len(os.Args) > 1 && os.Args[1] == "serve"
+	if serve {
+		// Errors exit the program since the flag set uses ExitOnError.
+		_ = flag.CommandLine.Parse(os.Args[2:])
+	} else {
+		flag.Parse()
+	}
+
 	if *cpuprofile != "" {
 		f, err := os.Create(*cpuprofile)
 		if err != nil {
@@ -27,7 +95,130 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	world := createWorld()
+	var err error
+	options := MakeRenderOptions()
+	options.SamplesPerPixel = *samples
+	options.Seed = *seed
+	options.Adaptive = *adaptive
+	options.TransparentBackground = *transparent
+	options.AdaptiveThreshold = *adaptiveThreshold
+	options.AdaptiveMaxDepth = *adaptiveMaxDepth
+	options.CropToRegion = *crop
+
+	if *regionFlag != "" {
+		options.Region, err = parseRegion(*regionFlag)
+		if err != nil {
+			usageError(err)
+		}
+	}
+
+	options.Passes, err = ParseRenderPasses(*passNames)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	integrator, err := ParseIntegrator(*integratorName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	occlusion := AmbientOcclusion{Samples: *occlusionSamples, MaxDistance: *occlusionDistance}
+	switch typed := integrator.(type) {
+	case WhittedIntegrator:
+		if *ambientOcclusion {
+			typed.Occlusion = occlusion
+		}
+		integrator = typed
+	case PathIntegrator:
+		typed.MaxBounces = *maxBounces
+		integrator = typed
+	case AmbientOcclusionIntegrator:
+		typed.Occlusion = occlusion
+		integrator = typed
+	}
+	options.Integrator = integrator
+
+	pattern, err := ParseSamplePattern(*samplePattern)
+	if err != nil {
+		log.Fatal(err)
+	}
+	options.SamplePattern = pattern
+
+	filter, err := ParseFilter(*filterName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	options.Filter = filter
+
+	projection, err := ParseProjection(*projectionName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if orthographic, ok := projection.(OrthographicProjection); ok {
+		orthographic.Size = *orthographicSize
+		projection = orthographic
+	}
+
+	backgroundColor, err := parseColor(*background)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	mapping, err := ParseUVMapping(*textureMapping)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	wrap, err := ParseTextureWrap(*textureWrap)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	middleMaterial := MakeMaterial()
+	middleMaterial.Color = MakeColor(0.1, 1, 0.5)
+	middleMaterial.Diffuse = 0.7
+	middleMaterial.Specular = 0.3
+	middleMaterial.BumpHeight = *bumpHeight
+	if *texturePath != "" {
+		middleMaterial.Texture, err = readTextureFromFile(*texturePath, mapping, wrap, true)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	// Normal and bump maps store directions and heights rather than colors,
+	// so they are read as they are.
+	if *normalMapPath != "" {
+		middleMaterial.NormalMap, err = readTextureFromFile(*normalMapPath, mapping, wrap, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *bumpMapPath == "noise" {
+		middleMaterial.BumpMap = MakeNoiseTexture(0.1)
+	} else if *bumpMapPath != "" {
+		middleMaterial.BumpMap, err = readTextureFromFile(*bumpMapPath, mapping, wrap, false)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	world := createWorld(middleMaterial)
+	world.Environment = MakeConstantEnvironment(backgroundColor)
+	if *environmentPath != "" {
+		image, err := readColorsFromFile(*environmentPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		environment := MakeImageEnvironment(image)
+		environment.Strength = *environmentStrength
+		world.Environment = environment
+	}
+	if *sky {
+		environment := MakeSkyEnvironment(MakeSunDirection(*sunElevation*math.Pi/180, *sunAzimuth*math.Pi/180), *turbidity)
+		world.Environment = environment
+		world.Sun = environment.Sun()
+		world.Light.Intensity = MakeColor(0, 0, 0)
+	}
 
 	canvasSize := 500
 	camera := MakeCamera(canvasSize, canvasSize/2, math.Pi/3)
@@ -36,15 +227,49 @@ func main() {
 	to := MakePoint(0, 1, 0)
 	up := MakeVector(0, 1, 0)
 	camera.Transform = ViewTransform(from, to, up)
+	camera.Projection = projection
+	camera.ShutterClose = *shutter
+	camera.Aperture = *aperture
+	camera.FocalDistance = *focalDistance
+	if camera.FocalDistance == 0 {
+		camera.FocalDistance = to.Subtract(from).Magnitude()
+	}
+
+	err = options.checkRegion(camera)
+	if err != nil {
+		usageError(err)
+	}
+
+	output := makeOutputOptions()
+
+	if serve {
+		servePreview(camera, world, options, output)
+		return
+	}
 
 	log.Println("Rendering world...")
-	canvas := Render(camera, world)
+	result := RenderWithOptions(camera, world, options)
 	log.Println("Finished rendering world.")
+	log.Printf(
+		"Traced %d rays (%d primary, %d adaptive across %d pixels)",
+		result.Stats.TotalRays(),
+		result.Stats.PrimaryRays,
+		result.Stats.AdaptiveRays,
+		result.Stats.SubdividedPixels,
+	)
 
-	writeCanvasToFile(canvas, "output.ppm")
+	err = writeCanvasToFile(result.Canvas, *outputPath, output)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = writePassesToFiles(result.Passes, *outputPath, output)
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-func createWorld() World {
+func createWorld(middleMaterial Material) World {
 	log.Println("Constructing world...")
 	wallMaterial := MakeMaterial()
 	wallMaterial.Color = MakeColor(1, 0.9, 0.9)
@@ -74,10 +299,6 @@ func createWorld() World {
 	middle := MakeSphereTransformed(
 		MakeTranslation(-0.5, 1, 0.5),
 	)
-	middleMaterial := MakeMaterial()
-	middleMaterial.Color = MakeColor(0.1, 1, 0.5)
-	middleMaterial.Diffuse = 0.7
-	middleMaterial.Specular = 0.3
 	middle.material = middleMaterial
 
 	right := MakeSphereTransformed(
@@ -89,10 +310,15 @@ func createWorld() World {
 	rightMaterial.Specular = 0.3
 	right.material = rightMaterial
 
-	left := MakeSphereTransformed(
-		MakeTranslation(-1.5, 0.33, -0.75).
-			Multiply(MakeScale(0.33, 0.33, 0.33)),
-	)
+	// The small sphere on the left rolls to the right while the camera's
+	// shutter is open.
+	leftStart := MakeKeyframe()
+	leftStart.Translation = MakeVector(-1.5, 0.33, -0.75)
+	leftStart.Scale = MakeVector(0.33, 0.33, 0.33)
+	leftEnd := leftStart
+	leftEnd.Translation = MakeVector(-1.1, 0.33, -0.75)
+	leftEnd.Rotation = MakeVector(0, 0, -0.4/0.33)
+	left := MakeSphereAnimated(MakeAnimatedTransform(leftStart, leftEnd))
 	leftMaterial := MakeMaterial()
 	leftMaterial.Color = MakeColor(1, 0.8, 0.1)
 	leftMaterial.Diffuse = 0.7
@@ -110,16 +336,131 @@ func createWorld() World {
 	return world
 }
 
-func writeCanvasToFile(canvas Canvas, filePath string) {
+// Parse a color given on the command line as comma separated red, green, and
+// blue intensities.
+func parseColor(value string) (Color, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 3 {
+		return Color{}, fmt.Errorf("expected a color as 'red,green,blue', got '%s'", value)
+	}
+
+	var channels [3]float64
+	for i, part := range parts {
+		channel, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return Color{}, fmt.Errorf("invalid color channel '%s' in '%s'", part, value)
+		}
+
+		channels[i] = channel
+	}
+
+	return MakeColor(channels[0], channels[1], channels[2]), nil
+}
+
+// Report an invalid combination of flags along with the usage of the program,
+// and exit with the status the flag package uses for invalid flags.
+func usageError(err error) {
+	fmt.Fprintln(flag.CommandLine.Output(), err)
+	flag.Usage()
+	os.Exit(2)
+}
+
+// Parse a region of the canvas given on the command line as comma separated
+// x, y, width, and height values.
+func parseRegion(value string) (image.Rectangle, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("expected a region as 'x,y,width,height', got '%s'", value)
+	}
+
+	var values [4]int
+	for i, part := range parts {
+		number, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("invalid value '%s' in region '%s'", part, value)
+		}
+
+		values[i] = number
+	}
+
+	if values[2] <= 0 || values[3] <= 0 {
+		return image.Rectangle{}, fmt.Errorf("region '%s' must have a positive width and height", value)
+	}
+
+	return image.Rect(values[0], values[1], values[0]+values[2], values[1]+values[3]), nil
+}
+
+// Create the options for writing images from the command line's flags.
+func makeOutputOptions() outputOptions {
+	toneMap, err := ParseToneMap(*toneMapName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *whitePoint <= 0 {
+		log.Fatalf("white point must be positive, got %v", *whitePoint)
+	}
+
+	transform := MakeOutputTransform()
+	transform.Exposure = *exposure
+	transform.ToneMap = toneMap
+	transform.WhitePoint = *whitePoint
+	transform.SRGB = *srgb
+
+	ditherMethod, err := ParseDitherMethod(*ditherName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dither := Dither{Method: ditherMethod, Seed: *ditherSeed}
+
+	output := outputOptions{
+		ppm:  MakePPMOptions(),
+		pam:  MakePAMOptions(),
+		png:  MakePNGOptions(),
+		jpeg: MakeJPEGOptions(),
+	}
+	output.ppm.MaxColorValue = *ppmMaxValue
+	output.ppm.Transform = transform
+	output.ppm.Dither = dither
+	if *ppmBinary {
+		output.ppm.Format = PPMBinary
+	}
+	output.pam.MaxColorValue = *ppmMaxValue
+	output.pam.Transform = transform
+	output.pam.Dither = dither
+	output.png.Transform = transform
+	output.png.Dither = dither
+	output.jpeg.Quality = *jpegQuality
+	output.jpeg.Transform = transform
+
+	return output
+}
+
+// Settings for writing images that only apply to specific file formats.
+type outputOptions struct {
+	ppm  PPMOptions
+	pam  PAMOptions
+	png  PNGOptions
+	jpeg JPEGOptions
+}
+
+// Write a canvas to a file. The format of the file is determined by the
+// file's extension.
+func writeCanvasToFile(canvas Canvas, filePath string, options outputOptions) (err error) {
+	format, write, err := canvasWriterForPath(filePath, options)
+	if err != nil {
+		return err
+	}
+
 	file, err := os.Create(filePath)
 	if err != nil {
-		log.Fatalf("Failed to create '%s': %v", filePath, err)
+		return fmt.Errorf("failed to create '%s': %w", filePath, err)
 	}
 	log.Printf("Created output file '%s'", filePath)
 
 	defer func() {
-		if err := file.Close(); err != nil {
-			log.Fatalf("Error closing '%s': %v", filePath, err)
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("error closing '%s': %w", filePath, closeErr)
 		}
 
 		log.Printf("Closed file '%s'", filePath)
@@ -129,15 +470,275 @@ func writeCanvasToFile(canvas Canvas, filePath string) {
 	// incremental write is done straight to disk and kills performance. This
 	// reduced write times from ~5 sec to < 1 sec.
 	fileWriter := bufio.NewWriter(file)
-	defer func() {
-		if err := fileWriter.Flush(); err != nil {
-			log.Fatalf("Error flushing file writer: %v", err)
+
+	log.Printf("Writing canvas to %s...", format)
+	if err := write(canvas, fileWriter); err != nil {
+		return fmt.Errorf("error writing %s to '%s': %w", format, filePath, err)
+	}
+
+	if err := fileWriter.Flush(); err != nil {
+		return fmt.Errorf("error flushing file writer: %w", err)
+	}
+	log.Printf("Finished writing canvas to %s.", format)
+
+	return nil
+}
+
+// Write each render pass to a file next to the rendered image. The name of the
+// pass is inserted before the image's extension, so the depth pass of
+// "output.png" is written to "output.depth.png". Passes hold data rather than
+// colors, so they are written without any display transform. PFM files keep
+// the raw values of each pass while other formats get a visualization.
+func writePassesToFiles(passes map[RenderPass]Canvas, imagePath string, options outputOptions) error {
+	options.ppm.Transform = MakeOutputTransform()
+	options.ppm.Dither = Dither{}
+	options.pam.Transform = MakeOutputTransform()
+	options.pam.Dither = Dither{}
+	options.png.Transform = MakeOutputTransform()
+	options.png.Dither = Dither{}
+	options.jpeg.Transform = MakeOutputTransform()
+
+	extension := filepath.Ext(imagePath)
+	base := strings.TrimSuffix(imagePath, extension)
+	raw := strings.ToLower(extension) == ".pfm"
+
+	for _, pass := range allRenderPasses {
+		canvas, ok := passes[pass]
+		if !ok {
+			continue
+		}
+
+		if !raw {
+			canvas = VisualizePass(pass, canvas)
+		}
+
+		err := writeCanvasToFile(canvas, fmt.Sprintf("%s.%s%s", base, pass, extension), options)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Get the name of the image format and a function that writes a canvas in
+// that format based on the extension of a file path.
+func canvasWriterForPath(filePath string, options outputOptions) (string, func(Canvas, io.Writer) error, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".ppm":
+		return "PPM", func(canvas Canvas, dest io.Writer) error {
+			return WriteCanvasToPPMWithOptions(canvas, dest, options.ppm)
+		}, nil
+	case ".pam":
+		return "PAM", func(canvas Canvas, dest io.Writer) error {
+			return WriteCanvasToPAM(canvas, dest, options.pam)
+		}, nil
+	case ".png":
+		return "PNG", func(canvas Canvas, dest io.Writer) error {
+			return WriteCanvasToPNGWithOptions(canvas, dest, options.png)
+		}, nil
+	case ".jpg", ".jpeg":
+		return "JPEG", func(canvas Canvas, dest io.Writer) error {
+			return WriteCanvasToJPEG(canvas, dest, options.jpeg)
+		}, nil
+	case ".pfm":
+		return "PFM", WriteCanvasToPFM, nil
+	case ".hdr":
+		return "HDR", WriteCanvasToHDR, nil
+	}
+
+	return "", nil, fmt.Errorf("unsupported output format for '%s'", filePath)
+}
+
+// Run the compare command, which compares two images and optionally writes a
+// heatmap of their differences. The exit status is 0 if the images match, 1 if
+// they differ, and 2 if they could not be compared.
+func runCompare(args []string) int {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	tolerance := flags.Float64("tolerance", 1.0/255, "largest difference in any color channel that is still considered a match")
+	diffPath := flags.String("diff", "", "file to write a heatmap of the differences to")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s compare [flags] <image> <image>\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	output := outputOptions{
+		ppm:  MakePPMOptions(),
+		pam:  MakePAMOptions(),
+		png:  MakePNGOptions(),
+		jpeg: MakeJPEGOptions(),
+	}
+
+	// Check the heatmap can be written before comparing, so an unsupported
+	// format isn't mistaken for images that differ.
+	if *diffPath != "" {
+		if _, _, err := canvasWriterForPath(*diffPath, output); err != nil {
+			log.Print(err)
+			return 2
+		}
+	}
+
+	var canvases [2]Canvas
+	for i, path := range flags.Args() {
+		canvas, err := readCanvasFromFile(path)
+		if err != nil {
+			log.Print(err)
+			return 2
+		}
+
+		canvases[i] = canvas
+	}
+
+	difference, err := CompareCanvases(canvases[0], canvases[1], *tolerance)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+
+	fmt.Printf("RMSE:      %.6f\n", difference.RMSE)
+	fmt.Printf("PSNR:      %.2f dB\n", difference.PSNR)
+	fmt.Printf("Max error: %.6f\n", difference.MaxError)
+	fmt.Printf("Differing: %d of %d pixels\n", difference.DifferingPixels, canvases[0].Width*canvases[0].Height)
+
+	if *diffPath != "" {
+		if err := writeCanvasToFile(difference.Heatmap, *diffPath, output); err != nil {
+			log.Print(err)
+			return 2
+		}
+	}
+
+	if !difference.Matches() {
+		return 1
+	}
+
+	return 0
+}
+
+// Read an image file into a canvas. The format of the file is determined by
+// the file's extension.
+func readCanvasFromFile(filePath string) (Canvas, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return Canvas{}, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+
+	var canvas Canvas
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".ppm":
+		canvas, err = ReadPPM(reader)
+	case ".pfm":
+		canvas, err = ReadPFM(reader)
+	case ".hdr":
+		canvas, err = ReadHDR(reader)
+	case ".png", ".jpg", ".jpeg":
+		var decoded image.Image
+		decoded, _, err = image.Decode(reader)
+		if err == nil {
+			canvas = MakeCanvasFromImage(decoded)
 		}
+	default:
+		return Canvas{}, fmt.Errorf("unsupported input format for '%s'", filePath)
+	}
+
+	if err != nil {
+		return Canvas{}, fmt.Errorf("failed to read '%s': %w", filePath, err)
+	}
+
+	return canvas, nil
+}
+
+// Read an image of colors from a file, such as a texture or an environment
+// map. Images in 8-bit formats are assumed to be encoded with the sRGB transfer
+// function, which is undone so that the colors are linear. PFM and HDR images
+// are already linear and are used as they are.
+func readColorsFromFile(filePath string) (Canvas, error) {
+	image, err := readCanvasFromFile(filePath)
+	if err != nil {
+		return Canvas{}, err
+	}
+
+	extension := strings.ToLower(filepath.Ext(filePath))
+	if extension == ".pfm" || extension == ".hdr" {
+		return image, nil
+	}
+
+	for y := 0; y < image.Height; y++ {
+		for x := 0; x < image.Width; x++ {
+			pixel := image.GetPixel(x, y)
+			image.SetPixel(x, y, MakeColor(
+				decodeSRGB(pixel.Red()),
+				decodeSRGB(pixel.Green()),
+				decodeSRGB(pixel.Blue()),
+			))
+		}
+	}
+
+	return image, nil
+}
+
+// Read an image texture from a file. Textures of colors should be decoded as
+// described by readColorsFromFile. Textures of data, like normal and bump
+// maps, should not be decoded so that their values are used exactly as they
+// are stored.
+func readTextureFromFile(filePath string, mapping UVMapping, wrap TextureWrap, decode bool) (Texture, error) {
+	read := readCanvasFromFile
+	if decode {
+		read = readColorsFromFile
+	}
+
+	image, err := read(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	texture := MakeImageTexture(image, mapping)
+	texture.Wrap = wrap
+
+	return texture, nil
+}
+
+// Render a world progressively while serving a preview of the image over HTTP.
+// The finished image is written to the output file, and the server keeps
+// running until the program is interrupted.
+func servePreview(camera Camera, world World, options RenderOptions, output outputOptions) {
+	if options.Adaptive || options.Passes != 0 {
+		log.Print("Adaptive sampling and render passes are ignored by the serve command.")
+	}
+
+	listener, err := net.Listen("tcp", *address)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := MakePreviewServer(output.png)
+	go func() {
+		log.Fatal(http.Serve(listener, server.Handler()))
 	}()
+	log.Printf("Serving preview at http://%s/", listener.Addr())
 
-	log.Println("Writing canvas to PPM...")
-	if err := WriteCanvasToPPM(canvas, fileWriter); err != nil {
-		log.Fatalf("Error writing PPM to '%s': %v", filePath, err)
+	log.Println("Rendering world progressively...")
+	result := RenderProgressive(camera, world, options, func(progress RenderProgress) {
+		if progress.Pass == 0 {
+			log.Println("Finished the coarse preview")
+		} else {
+			log.Printf("Finished pass %d of %d", progress.Pass, progress.Passes)
+		}
+		server.Update(progress)
+	})
+	log.Printf("Finished rendering world with %d rays.", result.Stats.TotalRays())
+
+	if err := writeCanvasToFile(result.Canvas, *outputPath, output); err != nil {
+		log.Fatal(err)
 	}
-	log.Println("Finished writing canvas to PPM.")
+
+	log.Println("Press Ctrl-C to stop the preview server.")
+	select {}
 }
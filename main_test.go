@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func makeTestOutputOptions() outputOptions {
+	return outputOptions{
+		ppm:  MakePPMOptions(),
+		pam:  MakePAMOptions(),
+		png:  MakePNGOptions(),
+		jpeg: MakeJPEGOptions(),
+	}
+}
+
+func TestCanvasWriterForPath(t *testing.T) {
+	testCases := []struct {
+		path       string
+		wantFormat string
+		wantErr    bool
+	}{
+		{"image.ppm", "PPM", false},
+		{"image.pam", "PAM", false},
+		{"image.png", "PNG", false},
+		{"image.jpg", "JPEG", false},
+		{"image.jpeg", "JPEG", false},
+		{"image.pfm", "PFM", false},
+		{"image.hdr", "HDR", false},
+		{"dir/IMAGE.PNG", "PNG", false},
+		{"image.gif", "", true},
+		{"image", "", true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.path, func(t *testing.T) {
+			format, write, err := canvasWriterForPath(tt.path, makeTestOutputOptions())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error = %v, got %v", tt.wantErr, err)
+			}
+
+			if format != tt.wantFormat {
+				t.Errorf("Expected format %q, got %q", tt.wantFormat, format)
+			}
+
+			if (write != nil) == tt.wantErr {
+				t.Errorf("Expected a writer only without an error")
+			}
+		})
+	}
+}
+
+func TestReadCanvasFromFile(t *testing.T) {
+	directory, err := ioutil.TempDir("", "read-canvas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	canvas := MakeCanvas(3, 2)
+	canvas.SetPixel(0, 0, MakeColor(1, 0, 0))
+	canvas.SetPixel(2, 1, MakeColor(0, 0, 1))
+
+	testCases := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"image.ppm", false},
+		{"image.png", false},
+		{"image.jpg", false},
+		{"image.jpeg", false},
+		{"image.pfm", false},
+		{"image.hdr", false},
+		{"IMAGE.PPM", false},
+		// PAM files can be written but not read.
+		{"image.pam", true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(directory, tt.name)
+			writeTestCanvas(t, canvas, path)
+
+			got, err := readCanvasFromFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error = %v, got %v", tt.wantErr, err)
+			}
+
+			if !tt.wantErr && (got.Width != canvas.Width || got.Height != canvas.Height) {
+				t.Errorf("Expected a %dx%d canvas, got %dx%d", canvas.Width, canvas.Height, got.Width, got.Height)
+			}
+		})
+	}
+
+	t.Run("unknown extension", func(t *testing.T) {
+		path := filepath.Join(directory, "image.gif")
+		if err := ioutil.WriteFile(path, []byte("GIF89a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := readCanvasFromFile(path); err == nil {
+			t.Errorf("Expected an error for an unsupported format")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := readCanvasFromFile(filepath.Join(directory, "missing.ppm")); err == nil {
+			t.Errorf("Expected an error for a missing file")
+		}
+	})
+}
+
+// Images in 8-bit formats hold colors encoded with the sRGB transfer function,
+// which should be decoded when they are read, while PFM and HDR images hold
+// linear colors which should be read as they are.
+func TestReadColorsFromFile(t *testing.T) {
+	directory, err := ioutil.TempDir("", "read-colors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	linear := MakeColor(0.2, 0.5, 0.05)
+	stored := MakeColor(encodeSRGB(linear.Red()), encodeSRGB(linear.Green()), encodeSRGB(linear.Blue()))
+	canvas := MakeCanvas(2, 2)
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			canvas.SetPixel(x, y, stored)
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		want      Color
+		tolerance float64
+	}{
+		{"image.ppm", linear, 0.005},
+		{"image.png", linear, 0.005},
+		{"image.jpg", linear, 0.02},
+		{"image.pfm", stored, 1e-6},
+		{"image.hdr", stored, 0.005},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(directory, tt.name)
+			writeTestCanvas(t, canvas, path)
+
+			got, err := readColorsFromFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pixel := got.GetPixel(1, 1)
+			if math.Abs(pixel.Red()-tt.want.Red()) > tt.tolerance ||
+				math.Abs(pixel.Green()-tt.want.Green()) > tt.tolerance ||
+				math.Abs(pixel.Blue()-tt.want.Blue()) > tt.tolerance {
+				t.Errorf("Expected %v, got %v", tt.want, pixel)
+			}
+		})
+	}
+}
+
+func TestRunCompare(t *testing.T) {
+	directory, err := ioutil.TempDir("", "compare")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	a := filepath.Join(directory, "a.ppm")
+	b := filepath.Join(directory, "b.ppm")
+	canvas := MakeCanvas(2, 2)
+	writeTestCanvas(t, canvas, a)
+	canvas.SetPixel(1, 1, MakeColor(1, 1, 1))
+	writeTestCanvas(t, canvas, b)
+
+	testCases := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"same", []string{a, a}, 0},
+		{"different", []string{a, b}, 1},
+		{"missing image", []string{a}, 2},
+		{"diff", []string{"-diff", filepath.Join(directory, "diff.png"), a, b}, 1},
+		{"unsupported diff format", []string{"-diff", filepath.Join(directory, "diff.gif"), a, b}, 2},
+		{"unwritable diff", []string{"-diff", filepath.Join(directory, "missing", "diff.png"), a, a}, 2},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runCompare(tt.args); got != tt.want {
+				t.Errorf("Expected exit status %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+// Write a canvas to a file in the format given by the file's extension.
+func writeTestCanvas(t *testing.T, canvas Canvas, path string) {
+	t.Helper()
+
+	_, write, err := canvasWriterForPath(path, makeTestOutputOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	if err := write(canvas, writer); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+}
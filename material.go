@@ -1,11 +1,45 @@
 package main
 
+import "reflect"
+
 type Material struct {
 	Color     Color
 	Ambient   float64
 	Diffuse   float64
 	Specular  float64
 	Shininess float64
+
+	// The texture giving the color of the material at each point, in place
+	// of the color. Materials without a texture are the same color
+	// everywhere. BSDFs keep their own colors, so the texture only affects
+	// their ambient light.
+	Texture Texture
+
+	// A texture encoding the direction of the normal at each point relative
+	// to the surface, for adding detail without extra geometry. The red,
+	// green, and blue values in the range [0, 1] map to the components along
+	// the surface's tangent, its bitangent, and its unperturbed normal in the
+	// range [-1, 1], so an untouched normal is a pale blue.
+	NormalMap Texture
+	// A texture giving the height of the surface at each point by its
+	// luminance. The normal is tilted as if the surface were raised by the
+	// height times the bump height.
+	BumpMap Texture
+	// The height in world units that the surface is raised to where the bump
+	// map is white.
+	BumpHeight float64
+
+	// The BSDF that determines how light is scattered by the material. When
+	// set, Lighting uses it in place of the Phong model, and only the color
+	// and ambient value of the material apply. Materials without a BSDF are
+	// treated as Lambertian by physically based integrators.
+	BSDF BSDF
+
+	// The color of light given off by the material.
+	Emission Color
+	// The brightness of the light given off by the material, which scales
+	// the emission color.
+	EmissionStrength float64
 }
 
 func MakeMaterial() Material {
@@ -15,14 +49,98 @@ func MakeMaterial() Material {
 		Diffuse:   0.9,
 		Specular:  0.9,
 		Shininess: 200.0,
+
+		BumpHeight: 0.05,
+
+		Emission:         MakeColor(0, 0, 0),
+		EmissionStrength: 1,
 	}
 }
 
-// Determine if one material is equivalent to another.
+// Create a material that glows with the given color and strength. The material
+// reflects no light of its own.
+func MakeEmissiveMaterial(emission Color, strength float64) Material {
+	material := MakeBSDFMaterial(MakeColor(0, 0, 0), LambertianBSDF{Albedo: MakeColor(0, 0, 0)})
+	material.Ambient = 0
+	material.Emission = emission
+	material.EmissionStrength = strength
+
+	return material
+}
+
+// Create a microfacet material with the given base color, metalness, and
+// roughness.
+func MakeMicrofacetMaterial(color Color, metallic, roughness float64) Material {
+	return MakeBSDFMaterial(color, MicrofacetBRDF{
+		BaseColor: color,
+		Metallic:  metallic,
+		Roughness: roughness,
+	})
+}
+
+// Create a material that scatters light with a BSDF. The color is used for the
+// ambient light and the albedo render pass.
+func MakeBSDFMaterial(color Color, bsdf BSDF) Material {
+	material := MakeMaterial()
+	material.Color = color
+	material.BSDF = bsdf
+
+	return material
+}
+
+// Determine if one material is equivalent to another. Textures and BSDFs are
+// equivalent if they are the same value; pointers must point to the same
+// texture or BSDF.
 func (mat Material) Equals(other Material) bool {
 	return mat.Color.Equals(other.Color) &&
 		Float64Equal(mat.Ambient, other.Ambient) &&
 		Float64Equal(mat.Diffuse, other.Diffuse) &&
 		Float64Equal(mat.Specular, other.Specular) &&
-		Float64Equal(mat.Shininess, other.Shininess)
+		Float64Equal(mat.Shininess, other.Shininess) &&
+		sameValue(mat.Texture, other.Texture) &&
+		sameValue(mat.NormalMap, other.NormalMap) &&
+		sameValue(mat.BumpMap, other.BumpMap) &&
+		Float64Equal(mat.BumpHeight, other.BumpHeight) &&
+		sameValue(mat.BSDF, other.BSDF) &&
+		mat.Emission.Equals(other.Emission) &&
+		Float64Equal(mat.EmissionStrength, other.EmissionStrength)
+}
+
+// Determine if two values held in interfaces are the same. Comparing
+// interfaces with == panics when they hold values of a type that can't be
+// compared, like a struct containing a slice, so those values are compared
+// element by element instead.
+func sameValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+
+	if !reflect.TypeOf(a).Comparable() {
+		return reflect.DeepEqual(a, b)
+	}
+
+	return a == b
+}
+
+// Get the light given off by the material.
+func (mat Material) emitted() Color {
+	return mat.Emission.Multiply(mat.EmissionStrength)
+}
+
+// Determine if the material gives off any light.
+func (mat Material) isEmissive() bool {
+	return !mat.emitted().Equals(MakeColor(0, 0, 0))
+}
+
+// Get the BSDF that scatters light for physically based integrators.
+func (mat Material) scattering() BSDF {
+	if mat.BSDF != nil {
+		return mat.BSDF
+	}
+
+	return LambertianBSDF{Albedo: mat.Color.Multiply(mat.Diffuse)}
 }
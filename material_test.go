@@ -24,9 +24,54 @@ func TestMakeMaterial(t *testing.T) {
 	if m.Shininess != 200.0 {
 		t.Errorf("Expected default shininess value to be %v, got %v", 200.0, m.Shininess)
 	}
+
+	if m.NormalMap != nil || m.BumpMap != nil {
+		t.Errorf("Expected no normal or bump map by default, got %v and %v", m.NormalMap, m.BumpMap)
+	}
+}
+
+func TestMakeMicrofacetMaterial(t *testing.T) {
+	m := MakeMicrofacetMaterial(MakeColor(1, 0.8, 0.3), 1, 0.25)
+
+	if !m.Color.Equals(MakeColor(1, 0.8, 0.3)) {
+		t.Errorf("Expected color to be %v, got %v", MakeColor(1, 0.8, 0.3), m.Color)
+	}
+
+	want := MicrofacetBRDF{BaseColor: MakeColor(1, 0.8, 0.3), Metallic: 1, Roughness: 0.25}
+	if m.BSDF != want {
+		t.Errorf("Expected BSDF to be %v, got %v", want, m.BSDF)
+	}
+}
+
+func TestMakeEmissiveMaterial(t *testing.T) {
+	m := MakeEmissiveMaterial(MakeColor(1, 0.5, 0.25), 4)
+
+	if want := MakeColor(4, 2, 1); !m.emitted().Equals(want) {
+		t.Errorf("Expected emitted light to be %v, got %v", want, m.emitted())
+	}
+
+	if !m.isEmissive() {
+		t.Errorf("Expected the material to be emissive")
+	}
+
+	if MakeMaterial().isEmissive() {
+		t.Errorf("Expected the default material not to be emissive")
+	}
+}
+
+// A texture backed by an image held by value, which makes it a type that can't
+// be compared with ==.
+type canvasTexture struct {
+	image Canvas
+}
+
+func (t canvasTexture) ColorAt(point Tuple) Color {
+	return t.image.GetPixel(0, 0)
 }
 
 func TestMaterial_Equals(t *testing.T) {
+	sharedTexture := MakeImageTexture(MakeCanvas(1, 1), SphericalMapping{})
+
 	testCases := []struct {
 		name      string
 		materialA Material
@@ -63,6 +108,90 @@ func TestMaterial_Equals(t *testing.T) {
 			Material{Shininess: 9001},
 			false,
 		},
+		{
+			"texture and no texture",
+			Material{Texture: MakeImageTexture(MakeCanvas(1, 1), SphericalMapping{})},
+			Material{},
+			false,
+		},
+		{
+			"normal map and no normal map",
+			Material{NormalMap: MakeImageTexture(MakeCanvas(1, 1), SphericalMapping{})},
+			Material{},
+			false,
+		},
+		{
+			"different bump maps",
+			Material{BumpMap: MakeNoiseTexture(1)},
+			Material{BumpMap: MakeNoiseTexture(2)},
+			false,
+		},
+		{
+			"different bump heights",
+			Material{BumpHeight: 0.1},
+			Material{BumpHeight: 0.2},
+			false,
+		},
+		{
+			"different BSDFs",
+			Material{BSDF: LambertianBSDF{Albedo: MakeColor(1, 1, 1)}},
+			Material{BSDF: MirrorBSDF{Color: MakeColor(1, 1, 1)}},
+			false,
+		},
+		{
+			"BSDF and no BSDF",
+			Material{BSDF: LambertianBSDF{Albedo: MakeColor(1, 1, 1)}},
+			Material{},
+			false,
+		},
+		{
+			"same BSDF",
+			Material{BSDF: DielectricBSDF{IndexOfRefraction: 1.5, Color: MakeColor(1, 1, 1)}},
+			Material{BSDF: DielectricBSDF{IndexOfRefraction: 1.5, Color: MakeColor(1, 1, 1)}},
+			true,
+		},
+		{
+			"different emission colors",
+			Material{Emission: MakeColor(1, 0, 0)},
+			Material{Emission: MakeColor(0, 1, 0)},
+			false,
+		},
+		{
+			"different emission strengths",
+			Material{EmissionStrength: 1},
+			Material{EmissionStrength: 5},
+			false,
+		},
+		{
+			"same image texture",
+			Material{Texture: sharedTexture},
+			Material{Texture: sharedTexture},
+			true,
+		},
+		{
+			"different image textures",
+			Material{Texture: MakeImageTexture(MakeCanvas(1, 1), SphericalMapping{})},
+			Material{Texture: MakeImageTexture(MakeCanvas(1, 1), SphericalMapping{})},
+			false,
+		},
+		{
+			"same uncomparable textures",
+			Material{Texture: canvasTexture{MakeCanvas(2, 2)}},
+			Material{Texture: canvasTexture{MakeCanvas(2, 2)}},
+			true,
+		},
+		{
+			"different uncomparable textures",
+			Material{Texture: canvasTexture{MakeCanvas(2, 2)}},
+			Material{Texture: canvasTexture{MakeCanvas(3, 3)}},
+			false,
+		},
+		{
+			"uncomparable texture and image texture",
+			Material{Texture: canvasTexture{MakeCanvas(1, 1)}},
+			Material{Texture: sharedTexture},
+			false,
+		},
 		{
 			"same material",
 			MakeMaterial(),
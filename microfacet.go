@@ -0,0 +1,167 @@
+package main
+
+import "math"
+
+// The reflectance of dielectrics at normal incidence. Most non-metals reflect
+// about 4% of light head on.
+const dielectricReflectance = 0.04
+
+// The smallest GGX roughness parameter used. Perfectly smooth surfaces have a
+// distribution that is infinitely narrow, which cannot be evaluated.
+const minMicrofacetAlpha = 1e-3
+
+// A microfacet BRDF following the metallic/roughness model. Surfaces are made
+// of tiny mirror-like facets oriented according to the GGX distribution, with
+// the shadowing and masking of facets by each other given by the Smith model
+// and the reflectance of each facet given by Schlick's approximation of the
+// Fresnel equations.
+//
+// Dielectrics reflect a small amount of light off of their facets and diffuse
+// the light that enters them, tinted by the base color. Metals reflect all of
+// the light off of their facets, tinted by the base color, and diffuse none.
+type MicrofacetBRDF struct {
+	// The color of the diffuse reflection of dielectrics and the specular
+	// reflection of metals.
+	BaseColor Color
+	// How metallic the surface is, from 0 for dielectrics to 1 for metals.
+	Metallic float64
+	// How rough the surface is, from 0 for perfectly smooth surfaces to 1 for
+	// very rough ones.
+	Roughness float64
+}
+
+// Get the roughness parameter of the GGX distribution. Squaring the roughness
+// makes it change more evenly in appearance.
+func (b MicrofacetBRDF) alpha() float64 {
+	return math.Max(minMicrofacetAlpha, b.Roughness*b.Roughness)
+}
+
+// Get the reflectance of the surface's facets at normal incidence.
+func (b MicrofacetBRDF) specularColor() Color {
+	dielectric := MakeColor(dielectricReflectance, dielectricReflectance, dielectricReflectance)
+
+	return dielectric.Multiply(1 - b.Metallic).Add(b.BaseColor.Multiply(b.Metallic))
+}
+
+// Compute the light reflected from the incoming direction to the outgoing
+// direction.
+func (b MicrofacetBRDF) Evaluate(normal, outgoing, incoming Tuple) Color {
+	normal = faceForward(normal, outgoing)
+	cosOutgoing := normal.Dot(outgoing)
+	cosIncoming := normal.Dot(incoming)
+	if cosOutgoing <= 0 || cosIncoming <= 0 {
+		return MakeColor(0, 0, 0)
+	}
+
+	halfway := outgoing.Add(incoming).Normalized()
+	alpha := b.alpha()
+	fresnel := schlickFresnel(b.specularColor(), incoming.Dot(halfway))
+
+	specular := fresnel.Multiply(
+		ggxDistribution(normal.Dot(halfway), alpha) *
+			smithMasking(cosOutgoing, alpha) *
+			smithMasking(cosIncoming, alpha) /
+			(4 * cosOutgoing * cosIncoming),
+	)
+
+	// Light that is not reflected off of the facets enters the surface and is
+	// scattered back out diffusely. This uses the diffuse term of the
+	// Ashikhmin-Shirley model, which only scatters light that both directions
+	// leave unreflected so that the surface never reflects more light than it
+	// receives.
+	white := MakeColor(1, 1, 1)
+	transmission := (1 - math.Pow(1-cosOutgoing/2, 5)) * (1 - math.Pow(1-cosIncoming/2, 5))
+	diffuse := white.Subtract(b.specularColor()).Blend(b.BaseColor).
+		Multiply((1 - b.Metallic) * 28 / (23 * math.Pi) * transmission)
+
+	return specular.Add(diffuse)
+}
+
+// Choose an incoming direction from either the facets facing in directions
+// chosen by the GGX distribution or the diffuse lobe. Directions that end up
+// below the surface reflect no light.
+func (b MicrofacetBRDF) Sample(normal, outgoing Tuple, sampler Sampler) (Tuple, Color, bool) {
+	facing := faceForward(normal, outgoing)
+
+	var incoming Tuple
+	if sampler.Float64() < b.specularProbability() {
+		halfway := sampleGGX(facing, b.alpha(), sampler.Float64(), sampler.Float64())
+		incoming = Reflect(outgoing.Negate(), halfway)
+	} else {
+		incoming = sampleCosineHemisphere(facing, sampler.Float64(), sampler.Float64())
+	}
+
+	return sampleWeight(b, normal, outgoing, incoming)
+}
+
+// Get the probability density of Sample choosing the incoming direction.
+func (b MicrofacetBRDF) PDF(normal, outgoing, incoming Tuple) float64 {
+	normal = faceForward(normal, outgoing)
+	cosOutgoing := normal.Dot(outgoing)
+	cosIncoming := normal.Dot(incoming)
+	if cosOutgoing <= 0 || cosIncoming <= 0 {
+		return 0
+	}
+
+	halfway := outgoing.Add(incoming).Normalized()
+	cosHalfway := normal.Dot(halfway)
+	// Reflecting about the halfway vector changes the density of directions
+	// by this Jacobian.
+	specular := ggxDistribution(cosHalfway, b.alpha()) * cosHalfway / (4 * outgoing.Dot(halfway))
+	diffuse := cosIncoming / math.Pi
+
+	probability := b.specularProbability()
+
+	return probability*specular + (1-probability)*diffuse
+}
+
+// Get the probability of sampling the specular reflection rather than the
+// diffuse reflection. Metals have no diffuse reflection.
+func (b MicrofacetBRDF) specularProbability() float64 {
+	return 0.5 + 0.5*b.Metallic
+}
+
+// The GGX normal distribution function: the density of facets whose normal has
+// the given cosine with the surface's normal.
+func ggxDistribution(cosHalfway, alpha float64) float64 {
+	if cosHalfway <= 0 {
+		return 0
+	}
+
+	alpha2 := alpha * alpha
+	denominator := cosHalfway*cosHalfway*(alpha2-1) + 1
+
+	return alpha2 / (math.Pi * denominator * denominator)
+}
+
+// The Smith masking function for the GGX distribution: the fraction of facets
+// facing a direction with the given cosine that are not hidden by other facets.
+func smithMasking(cosine, alpha float64) float64 {
+	alpha2 := alpha * alpha
+
+	return 2 * cosine / (cosine + math.Sqrt(alpha2+(1-alpha2)*cosine*cosine))
+}
+
+// Schlick's approximation of the Fresnel reflectance of a surface with the
+// given reflectance at normal incidence.
+func schlickFresnel(normalReflectance Color, cosine float64) Color {
+	white := MakeColor(1, 1, 1)
+	factor := math.Pow(1-math.Max(0, math.Min(1, cosine)), 5)
+
+	return normalReflectance.Add(white.Subtract(normalReflectance).Multiply(factor))
+}
+
+// Choose a facet normal in proportion to the GGX distribution times its cosine
+// with the surface's normal.
+func sampleGGX(normal Tuple, alpha, u, v float64) Tuple {
+	tan2Theta := alpha * alpha * u / (1 - u)
+	cosTheta := 1 / math.Sqrt(1+tan2Theta)
+	sinTheta := math.Sqrt(math.Max(0, 1-cosTheta*cosTheta))
+	phi := 2 * math.Pi * v
+
+	tangent, bitangent := makeOrthonormalBasis(normal)
+
+	return tangent.Multiply(sinTheta * math.Cos(phi)).
+		Add(bitangent.Multiply(sinTheta * math.Sin(phi))).
+		Add(normal.Multiply(cosTheta))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// The projected area of the facets of any distribution must add up to the area
+// of the surface.
+func TestGGXDistribution_Normalized(t *testing.T) {
+	for _, alpha := range []float64{0.05, 0.2, 0.5, 1} {
+		steps := 100000
+		total := 0.0
+		for i := 0; i < steps; i++ {
+			theta := (float64(i) + 0.5) / float64(steps) * math.Pi / 2
+			total += ggxDistribution(math.Cos(theta), alpha) * math.Cos(theta) * math.Sin(theta)
+		}
+		total *= 2 * math.Pi * math.Pi / 2 / float64(steps)
+
+		if math.Abs(total-1) > 1e-3 {
+			t.Errorf("Expected the distribution with alpha %v to integrate to 1, got %v", alpha, total)
+		}
+	}
+}
+
+// The weak white furnace test: the facets visible from any direction must
+// project to the surface's area seen from that direction.
+func TestSmithMasking_WeakWhiteFurnace(t *testing.T) {
+	for _, alpha := range []float64{0.1, 0.5, 1} {
+		for _, cosine := range []float64{1, 0.5, 0.1} {
+			outgoing := MakeVector(math.Sqrt(1-cosine*cosine), 0, cosine)
+
+			// Integrate over the hemisphere of facet normals.
+			thetaSteps, phiSteps := 2000, 200
+			total := 0.0
+			for i := 0; i < thetaSteps; i++ {
+				theta := (float64(i) + 0.5) / float64(thetaSteps) * math.Pi / 2
+				for j := 0; j < phiSteps; j++ {
+					phi := (float64(j) + 0.5) / float64(phiSteps) * 2 * math.Pi
+					halfway := MakeVector(math.Sin(theta)*math.Cos(phi), math.Sin(theta)*math.Sin(phi), math.Cos(theta))
+					total += ggxDistribution(halfway.Z, alpha) * math.Max(0, outgoing.Dot(halfway)) * math.Sin(theta)
+				}
+			}
+			total *= smithMasking(cosine, alpha) / cosine * (math.Pi / 2 / float64(thetaSteps)) * (2 * math.Pi / float64(phiSteps))
+
+			if math.Abs(total-1) > 0.01 {
+				t.Errorf("Expected the visible facets with alpha %v at cosine %v to integrate to 1, got %v", alpha, cosine, total)
+			}
+		}
+	}
+}
+
+// A white surface lit evenly from every direction can reflect at most all of
+// the light reaching it. Smooth surfaces lose no light to shadowing between
+// facets, so they reflect almost all of it.
+func TestMicrofacetBRDF_WhiteFurnace(t *testing.T) {
+	testCases := []struct {
+		name      string
+		metallic  float64
+		roughness float64
+		minimum   float64
+	}{
+		{"smooth dielectric", 0, 0, 0.7},
+		{"rough dielectric", 0, 1, 0.2},
+		{"smooth metal", 1, 0, 0.99},
+		{"glossy metal", 1, 0.3, 0.85},
+		{"rough metal", 1, 1, 0.3},
+		{"half metal", 0.5, 0.5, 0.5},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			brdf := MicrofacetBRDF{BaseColor: MakeColor(1, 1, 1), Metallic: tt.metallic, Roughness: tt.roughness}
+			normal := MakeVector(0, 0, 1)
+			random := rand.New(rand.NewSource(0))
+
+			for _, cosine := range []float64{1, 0.7, 0.3, 0.1} {
+				outgoing := MakeVector(math.Sqrt(1-cosine*cosine), 0, cosine)
+
+				samples := 20000
+				total := MakeColor(0, 0, 0)
+				for i := 0; i < samples; i++ {
+					_, weight, _ := brdf.Sample(normal, outgoing, random)
+					total = total.Add(weight)
+				}
+				albedo := total.Multiply(1 / float64(samples)).Red()
+
+				if albedo > 1.01 {
+					t.Errorf("Expected at most all light to be reflected at cosine %v, got %v", cosine, albedo)
+				}
+				if albedo < tt.minimum {
+					t.Errorf("Expected at least %v of the light to be reflected at cosine %v, got %v", tt.minimum, cosine, albedo)
+				}
+			}
+		})
+	}
+}
+
+// The weights returned by Sample must agree with the values of the BRDF, so
+// estimating the reflected light either way gives the same result.
+func TestMicrofacetBRDF_Sample(t *testing.T) {
+	brdf := MicrofacetBRDF{BaseColor: MakeColor(0.9, 0.6, 0.3), Metallic: 0.5, Roughness: 0.6}
+	normal := MakeVector(0, 1, 0)
+	outgoing := MakeVector(0.6, 0.8, 0)
+	random := rand.New(rand.NewSource(0))
+
+	samples := 200000
+	sampled := MakeColor(0, 0, 0)
+	uniform := MakeColor(0, 0, 0)
+	for i := 0; i < samples; i++ {
+		_, weight, _ := brdf.Sample(normal, outgoing, random)
+		sampled = sampled.Add(weight)
+
+		incoming := sampleCosineHemisphere(normal, random.Float64(), random.Float64())
+		uniform = uniform.Add(brdf.Evaluate(normal, outgoing, incoming).Multiply(math.Pi))
+	}
+	sampled = sampled.Multiply(1 / float64(samples))
+	uniform = uniform.Multiply(1 / float64(samples))
+
+	diff := sampled.Subtract(uniform)
+	for _, channel := range []float64{diff.Red(), diff.Green(), diff.Blue()} {
+		if math.Abs(channel) > 0.01 {
+			t.Fatalf("Expected sampling to give %v, got %v", uniform, sampled)
+		}
+	}
+}
+
+func TestMicrofacetBRDF_Evaluate(t *testing.T) {
+	brdf := MicrofacetBRDF{BaseColor: MakeColor(0.8, 0.2, 0.4), Metallic: 0.3, Roughness: 0.4}
+	normal := MakeVector(0, 0, 1)
+	a := MakeVector(0.3, 0.4, 0.866).Normalized()
+	b := MakeVector(-0.8, 0.1, 0.2).Normalized()
+	below := MakeVector(0.5, 0, -0.5).Normalized()
+
+	if forward, backward := brdf.Evaluate(normal, a, b), brdf.Evaluate(normal, b, a); !forward.Equals(backward) {
+		t.Errorf("Expected the BRDF to be reciprocal, got %v and %v", forward, backward)
+	}
+
+	black := MakeColor(0, 0, 0)
+	if got := brdf.Evaluate(normal, a, below); !got.Equals(black) {
+		t.Errorf("Expected no light to be reflected from below the surface, got %v", got)
+	}
+	if got := brdf.PDF(normal, a, below); got != 0 {
+		t.Errorf("Expected directions below the surface to never be sampled, got a density of %v", got)
+	}
+}
+
+func TestSchlickFresnel(t *testing.T) {
+	reflectance := MakeColor(0.04, 0.5, 1)
+
+	if got := schlickFresnel(reflectance, 1); !got.Equals(reflectance) {
+		t.Errorf("Expected the reflectance head on to be %v, got %v", reflectance, got)
+	}
+
+	white := MakeColor(1, 1, 1)
+	if got := schlickFresnel(reflectance, 0); !got.Equals(white) {
+		t.Errorf("Expected the reflectance at grazing angles to be %v, got %v", white, got)
+	}
+}
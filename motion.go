@@ -0,0 +1,84 @@
+package main
+
+import "math"
+
+// A keyframe describes the placement of an object at a single moment by its
+// individual components. Keeping the components separate allows the placement
+// to be smoothly interpolated between keyframes, which is not possible with a
+// combined transformation matrix.
+type Keyframe struct {
+	// The distance to move the object along each axis.
+	Translation Tuple
+	// The rotation around the x, y, and z axes in radians. Rotations are
+	// applied around the x-axis first and the z-axis last.
+	Rotation Tuple
+	// The factor to scale the object by along each axis.
+	Scale Tuple
+}
+
+// Create a keyframe that leaves an object untransformed.
+func MakeKeyframe() Keyframe {
+	return Keyframe{
+		Translation: MakeVector(0, 0, 0),
+		Rotation:    MakeVector(0, 0, 0),
+		Scale:       MakeVector(1, 1, 1),
+	}
+}
+
+// Get the transformation matrix described by the keyframe. The object is
+// scaled, then rotated, then translated.
+func (k Keyframe) Matrix() Matrix {
+	return MakeTranslation(k.Translation.X, k.Translation.Y, k.Translation.Z).
+		Multiply(MakeZRotation(k.Rotation.Z)).
+		Multiply(MakeYRotation(k.Rotation.Y)).
+		Multiply(MakeXRotation(k.Rotation.X)).
+		Multiply(MakeScale(k.Scale.X, k.Scale.Y, k.Scale.Z))
+}
+
+// Linearly interpolate each component of a keyframe towards another keyframe.
+// An amount of 0 gives this keyframe and an amount of 1 gives the other.
+func (k Keyframe) Lerp(other Keyframe, amount float64) Keyframe {
+	lerp := func(a, b Tuple) Tuple {
+		return a.Add(b.Subtract(a).Multiply(amount))
+	}
+
+	return Keyframe{
+		Translation: lerp(k.Translation, other.Translation),
+		Rotation:    lerp(k.Rotation, other.Rotation),
+		Scale:       lerp(k.Scale, other.Scale),
+	}
+}
+
+// An animated transform moves an object from a starting placement to an ending
+// placement over a period of time.
+type AnimatedTransform struct {
+	Start     Keyframe
+	StartTime float64
+
+	End     Keyframe
+	EndTime float64
+}
+
+// Create an animated transform that moves between two keyframes over the time
+// interval [0, 1], which matches a camera whose shutter opens at 0 and closes
+// at 1.
+func MakeAnimatedTransform(start, end Keyframe) AnimatedTransform {
+	return AnimatedTransform{
+		Start:     start,
+		StartTime: 0,
+		End:       end,
+		EndTime:   1,
+	}
+}
+
+// Get the transformation matrix at a specific time. Times before the start or
+// after the end of the animation hold the object at the nearest keyframe.
+func (a AnimatedTransform) TransformAt(time float64) Matrix {
+	amount := 0.0
+	if a.EndTime != a.StartTime {
+		amount = (time - a.StartTime) / (a.EndTime - a.StartTime)
+		amount = math.Max(0, math.Min(1, amount))
+	}
+
+	return a.Start.Lerp(a.End, amount).Matrix()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMakeKeyframe(t *testing.T) {
+	if got := MakeKeyframe().Matrix(); !got.Equals(IdentityMatrix4) {
+		t.Errorf("Expected identity matrix, got %v", got)
+	}
+}
+
+func TestKeyframe_Matrix(t *testing.T) {
+	keyframe := MakeKeyframe()
+	keyframe.Translation = MakeVector(1, 2, 3)
+	keyframe.Rotation = MakeVector(0, math.Pi/2, 0)
+	keyframe.Scale = MakeVector(2, 2, 2)
+
+	// The point is scaled to (2, 0, 0), rotated to (0, 0, -2), then
+	// translated.
+	want := MakePoint(1, 2, 1)
+
+	if got := keyframe.Matrix().TupleMultiply(MakePoint(1, 0, 0)); !want.Equals(got) {
+		t.Errorf("Expected transformed point %v, got %v", want, got)
+	}
+}
+
+func TestKeyframe_Lerp(t *testing.T) {
+	start := MakeKeyframe()
+	end := MakeKeyframe()
+	end.Translation = MakeVector(4, 0, -2)
+	end.Rotation = MakeVector(0, 0, math.Pi)
+	end.Scale = MakeVector(3, 1, 1)
+
+	got := start.Lerp(end, 0.25)
+
+	if want := MakeVector(1, 0, -0.5); !want.Equals(got.Translation) {
+		t.Errorf("Expected translation %v, got %v", want, got.Translation)
+	}
+
+	if want := MakeVector(0, 0, math.Pi/4); !want.Equals(got.Rotation) {
+		t.Errorf("Expected rotation %v, got %v", want, got.Rotation)
+	}
+
+	if want := MakeVector(1.5, 1, 1); !want.Equals(got.Scale) {
+		t.Errorf("Expected scale %v, got %v", want, got.Scale)
+	}
+}
+
+func TestAnimatedTransform_TransformAt(t *testing.T) {
+	start := MakeKeyframe()
+	end := MakeKeyframe()
+	end.Translation = MakeVector(10, 0, 0)
+	motion := MakeAnimatedTransform(start, end)
+
+	testCases := []struct {
+		name string
+		time float64
+		want Matrix
+	}{
+		{"start", 0, MakeTranslation(0, 0, 0)},
+		{"middle", 0.5, MakeTranslation(5, 0, 0)},
+		{"end", 1, MakeTranslation(10, 0, 0)},
+		{"before start", -1, MakeTranslation(0, 0, 0)},
+		{"after end", 2, MakeTranslation(10, 0, 0)},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := motion.TransformAt(tt.time); !tt.want.Equals(got) {
+				t.Errorf("Expected transform %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import "math"
+
+// A noise texture is a procedural texture of smoothly varying shades of gray,
+// following Perlin's gradient noise. It has no visible repetition, which makes
+// it useful as a bump map for rough or uneven surfaces.
+type NoiseTexture struct {
+	// The size in object space units of the features of the noise.
+	Scale float64
+}
+
+// Create a noise texture with features of the given size.
+func MakeNoiseTexture(scale float64) NoiseTexture {
+	return NoiseTexture{Scale: scale}
+}
+
+// Get the shade of gray of the noise at a point given in object space. Shades
+// lie in the range [0, 1] and average to one half.
+func (t NoiseTexture) ColorAt(point Tuple) Color {
+	value := 0.5 + 0.5*perlinNoise(point.X/t.Scale, point.Y/t.Scale, point.Z/t.Scale)
+	value = math.Max(0, math.Min(1, value))
+
+	return MakeColor(value, value, value)
+}
+
+// Get the value of Perlin's improved gradient noise at a point. Values lie
+// roughly in the range [-1, 1], and are zero at points with integer
+// coordinates.
+func perlinNoise(x, y, z float64) float64 {
+	cellX, cellY, cellZ := math.Floor(x), math.Floor(y), math.Floor(z)
+	x, y, z = x-cellX, y-cellY, z-cellZ
+	i, j, k := int64(cellX), int64(cellY), int64(cellZ)
+
+	// Blend the contribution of the gradient at each corner of the cell.
+	corner := func(di, dj, dk int64) float64 {
+		gradient := latticeGradient(i+di, j+dj, k+dk)
+
+		return gradient.X*(x-float64(di)) + gradient.Y*(y-float64(dj)) + gradient.Z*(z-float64(dk))
+	}
+	u, v, w := perlinFade(x), perlinFade(y), perlinFade(z)
+
+	return lerp(w,
+		lerp(v,
+			lerp(u, corner(0, 0, 0), corner(1, 0, 0)),
+			lerp(u, corner(0, 1, 0), corner(1, 1, 0))),
+		lerp(v,
+			lerp(u, corner(0, 0, 1), corner(1, 0, 1)),
+			lerp(u, corner(0, 1, 1), corner(1, 1, 1))),
+	)
+}
+
+// The gradients of the noise, which point from the center of a cube to the
+// middles of its edges.
+var perlinGradients = [12]Tuple{
+	MakeVector(1, 1, 0), MakeVector(-1, 1, 0), MakeVector(1, -1, 0), MakeVector(-1, -1, 0),
+	MakeVector(1, 0, 1), MakeVector(-1, 0, 1), MakeVector(1, 0, -1), MakeVector(-1, 0, -1),
+	MakeVector(0, 1, 1), MakeVector(0, -1, 1), MakeVector(0, 1, -1), MakeVector(0, -1, -1),
+}
+
+// Choose the gradient of the noise at a point of the integer lattice by
+// hashing its coordinates.
+func latticeGradient(i, j, k int64) Tuple {
+	hash := uint64(i)*0x9e3779b97f4a7c15 ^ uint64(j)*0xc2b2ae3d27d4eb4f ^ uint64(k)*0x165667b19e3779f9
+	hash ^= hash >> 29
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 32
+
+	return perlinGradients[hash%uint64(len(perlinGradients))]
+}
+
+// Ease a fraction in the range [0, 1] so that the noise changes smoothly across
+// the edges of the lattice.
+func perlinFade(t float64) float64 {
+	return t * t * t * (t*(t*6-15) + 10)
+}
+
+// Linearly interpolate between two values.
+func lerp(t, a, b float64) float64 {
+	return a + t*(b-a)
+}
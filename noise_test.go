@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNoiseTexture_ColorAt(t *testing.T) {
+	texture := MakeNoiseTexture(0.5)
+
+	// The noise is zero at the points of the lattice.
+	want := MakeColor(0.5, 0.5, 0.5)
+	if got := texture.ColorAt(MakePoint(1.5, -2, 0.5)); !want.Equals(got) {
+		t.Errorf("Expected color %v at a lattice point, got %v", want, got)
+	}
+
+	varied := false
+	previous := texture.ColorAt(MakePoint(0, 0.3, 0.7))
+	for i := 1; i <= 1000; i++ {
+		point := MakePoint(float64(i)*0.001, 0.3, 0.7)
+		got := texture.ColorAt(point)
+
+		if got.Red() < 0 || got.Red() > 1 || got.Red() != got.Green() || got.Red() != got.Blue() {
+			t.Fatalf("Expected a shade of gray in the range [0, 1] at %v, got %v", point, got)
+		}
+
+		if math.Abs(got.Red()-previous.Red()) > 0.01 {
+			t.Fatalf("Expected the noise to change smoothly at %v, got %v after %v", point, got, previous)
+		}
+
+		if !got.Equals(want) {
+			varied = true
+		}
+		previous = got
+	}
+
+	if !varied {
+		t.Errorf("Expected the noise to vary")
+	}
+}
+
+func TestNoiseTexture_ColorAt_Scale(t *testing.T) {
+	small := MakeNoiseTexture(0.5)
+	large := MakeNoiseTexture(2)
+
+	want := small.ColorAt(MakePoint(0.1, 0.2, 0.3))
+	if got := large.ColorAt(MakePoint(0.4, 0.8, 1.2)); !want.Equals(got) {
+		t.Errorf("Expected color %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+// The distance in world units between the points compared to find the slope of
+// a bump map.
+const bumpSampleDistance = 1e-4
+
+// Get the normal used to shade a point on an object, tilted by the material's
+// normal and bump maps. The point and the object's true normal are given in
+// world space. Materials without either map leave the normal as it is.
+func (mat Material) perturbNormal(object Object, point, normal Tuple) Tuple {
+	if mat.NormalMap == nil && mat.BumpMap == nil {
+		return normal
+	}
+
+	toObject := object.Transform().Inverted()
+	tangent, bitangent := tangentBasis(object, point, normal)
+
+	if mat.NormalMap != nil {
+		color := mat.NormalMap.ColorAt(toObject.TupleMultiply(point))
+		normal = tangent.Multiply(2*color.Red() - 1).
+			Add(bitangent.Multiply(2*color.Green() - 1)).
+			Add(normal.Multiply(2*color.Blue() - 1)).
+			Normalized()
+		tangent, bitangent = orthonormalTangents(tangent, normal)
+	}
+
+	if mat.BumpMap != nil {
+		height := func(offset Tuple) float64 {
+			return mat.BumpMap.ColorAt(toObject.TupleMultiply(point.Add(offset))).Luminance() * mat.BumpHeight
+		}
+
+		// Tilt the normal against the slope of the raised surface along the
+		// tangent and bitangent.
+		slopeTangent := (height(tangent.Multiply(bumpSampleDistance)) -
+			height(tangent.Multiply(-bumpSampleDistance))) / (2 * bumpSampleDistance)
+		slopeBitangent := (height(bitangent.Multiply(bumpSampleDistance)) -
+			height(bitangent.Multiply(-bumpSampleDistance))) / (2 * bumpSampleDistance)
+
+		normal = normal.
+			Subtract(tangent.Multiply(slopeTangent)).
+			Subtract(bitangent.Multiply(slopeBitangent)).
+			Normalized()
+	}
+
+	return normal
+}
+
+// Get the tangent and bitangent of an object at a point given in world space,
+// which together with the normal make up the space that normal maps are given
+// in. Objects that aren't tangent surfaces get an arbitrary tangent.
+func tangentBasis(object Object, point, normal Tuple) (Tuple, Tuple) {
+	surface, ok := object.(TangentSurface)
+	if !ok {
+		return makeOrthonormalBasis(normal)
+	}
+
+	return orthonormalTangents(surface.TangentAt(point), normal)
+}
+
+// Make a tangent perpendicular to the normal by removing the part of it that
+// lies along the normal, and find the bitangent perpendicular to both. The
+// bitangent points in the direction the v coordinate of the spherical mapping
+// increases in when the tangent points in the direction u increases in.
+func orthonormalTangents(tangent, normal Tuple) (Tuple, Tuple) {
+	tangent = tangent.Subtract(normal.Multiply(tangent.Dot(normal)))
+	if tangent.Magnitude() < floatEpsilon {
+		return makeOrthonormalBasis(normal)
+	}
+
+	tangent = tangent.Normalized()
+
+	return tangent, tangent.Cross(normal)
+}
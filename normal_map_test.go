@@ -0,0 +1,122 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Make a texture that is the same color everywhere.
+func makeSolidTexture(color Color) *ImageTexture {
+	image := MakeCanvas(1, 1)
+	image.SetPixel(0, 0, color)
+
+	return MakeImageTexture(image, SphericalMapping{})
+}
+
+func TestIntersection_PrepareComputations_NormalMap(t *testing.T) {
+	testCases := []struct {
+		name   string
+		color  Color
+		origin Tuple
+		want   Tuple
+	}{
+		{"untouched", MakeColor(0.5, 0.5, 1), MakePoint(0, 0, -5), MakeVector(0, 0, -1)},
+		{"along the tangent", MakeColor(1, 0.5, 0.5), MakePoint(0, 0, -5), MakeVector(1, 0, 0)},
+		{"along the bitangent", MakeColor(0.5, 1, 0.5), MakePoint(0, 0, -5), MakeVector(0, 1, 0)},
+		{
+			"tilted halfway to the tangent",
+			MakeColor(0.5+math.Sqrt2/4, 0.5, 0.5+math.Sqrt2/4),
+			MakePoint(0, 0, -5),
+			MakeVector(math.Sqrt2/2, 0, -math.Sqrt2/2),
+		},
+		// Inside the sphere the normal map tilts the normal the same way, and
+		// the result is flipped to face the eye.
+		{"inside", MakeColor(0.5, 1, 0.5), MakePoint(0, 0, 0), MakeVector(0, -1, 0)},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sphere := MakeSphere()
+			sphere.material.NormalMap = makeSolidTexture(tt.color)
+
+			ray := MakeRay(tt.origin, MakeVector(0, 0, 1))
+			hit, _ := sphere.Intersect(ray).Hit()
+			comp := hit.PrepareComputations(ray)
+
+			if !tt.want.Equals(comp.NormalVector) {
+				t.Errorf("Expected normal %v, got %v", tt.want, comp.NormalVector)
+			}
+
+			// The over point still sits just outside of the true surface.
+			trueNormal := sphere.NormalAt(comp.Point)
+			if comp.Inside {
+				trueNormal = trueNormal.Negate()
+			}
+			if want := comp.Point.Add(trueNormal.Multiply(floatEpsilon)); !want.Equals(comp.OverPoint) {
+				t.Errorf("Expected over point %v, got %v", want, comp.OverPoint)
+			}
+		})
+	}
+}
+
+func TestMaterial_perturbNormal_Bump(t *testing.T) {
+	// An image that is black on the left and white on the right, which rises
+	// evenly between the centers of its pixels at x = 0.25 and x = 0.75.
+	ramp := MakeCanvas(2, 1)
+	ramp.SetPixel(1, 0, MakeColor(1, 1, 1))
+	rampTexture := MakeImageTexture(ramp, PlanarMapping{})
+	rampTexture.Wrap = TextureWrapClamp
+
+	testCases := []struct {
+		name    string
+		bumpMap Texture
+		height  float64
+		want    Tuple
+	}{
+		{"flat", makeSolidTexture(MakeColor(1, 1, 1)), 0.5, MakeVector(0, 1, 0)},
+		{"ramp", rampTexture, 0.5, MakeVector(-math.Sqrt2/2, math.Sqrt2/2, 0)},
+		{"shallow ramp", rampTexture, 0.25, MakeVector(-0.5, 1, 0).Normalized()},
+		{"no height", rampTexture, 0, MakeVector(0, 1, 0)},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			material := MakeMaterial()
+			material.BumpMap = tt.bumpMap
+			material.BumpHeight = tt.height
+
+			// The surface rises by twice the bump height per unit along x,
+			// so the normal leans back towards -x.
+			got := material.perturbNormal(MakeSphere(), MakePoint(0.5, 0, 0), MakeVector(0, 1, 0))
+			if !tt.want.Equals(got) {
+				t.Errorf("Expected normal %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// A sphere that doesn't provide its tangents.
+type untangentedSphere struct {
+	sphere Sphere
+}
+
+func (s untangentedSphere) Intersect(ray Ray) Intersections { return s.sphere.Intersect(ray) }
+func (s untangentedSphere) Material() Material              { return s.sphere.Material() }
+func (s untangentedSphere) NormalAt(point Tuple) Tuple      { return s.sphere.NormalAt(point) }
+func (s untangentedSphere) Transform() Matrix               { return s.sphere.Transform() }
+
+// Objects that aren't tangent surfaces still get a basis perpendicular to the
+// normal.
+func TestTangentBasis(t *testing.T) {
+	normal := MakeVector(0, 0.6, 0.8)
+
+	for _, object := range []Object{MakeSphere(), untangentedSphere{MakeSphere()}} {
+		tangent, bitangent := tangentBasis(object, MakePoint(0, 0.6, 0.8), normal)
+
+		if !Float64Equal(0, tangent.Dot(normal)) || !Float64Equal(0, bitangent.Dot(normal)) || !Float64Equal(0, tangent.Dot(bitangent)) {
+			t.Errorf("Expected %v, %v, and %v to be perpendicular", tangent, bitangent, normal)
+		}
+
+		if !Float64Equal(1, tangent.Magnitude()) || !Float64Equal(1, bitangent.Magnitude()) {
+			t.Errorf("Expected unit tangents, got %v and %v", tangent, bitangent)
+		}
+	}
+}
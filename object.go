@@ -16,3 +16,22 @@ type Object interface {
 	// Get the object's transformation matrix.
 	Transform() Matrix
 }
+
+// A tangent surface is an object with a direction across its surface at each
+// point, which orients normal maps.
+type TangentSurface interface {
+	// Get a vector tangent to the object's surface at a point given in world
+	// space.
+	TangentAt(Tuple) Tuple
+}
+
+// A sampled surface is an object that can choose random points on its surface.
+// Emissive objects that are sampled surfaces are used as light sources by the
+// path integrator.
+type SampledSurface interface {
+	// Choose a random point on the object's surface at the given time, using
+	// two random values in the range [0, 1). The normal at the point and the
+	// probability density of choosing the point per unit of area are also
+	// returned.
+	SampleSurface(u, v, time float64) (point Tuple, normal Tuple, pdf float64)
+}
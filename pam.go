@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const (
+	PAMVersion = "P7"
+	// The tuple type of PAM images with red, green, blue, and alpha channels.
+	PAMTupleTypeRGBAlpha = "RGB_ALPHA"
+)
+
+// Options controlling how a canvas is written as a PAM.
+type PAMOptions struct {
+	// The value representing full intensity. Values above 255 use two bytes per
+	// value. Must be in the range [1, 65535].
+	MaxColorValue int
+	// The transform applied to colors before they are scaled to the color
+	// range. Alpha values are never transformed.
+	Transform OutputTransform
+	// The dithering applied while quantizing colors.
+	Dither Dither
+}
+
+// Create PAM options that write 8-bit color values linearly.
+func MakePAMOptions() PAMOptions {
+	return PAMOptions{
+		MaxColorValue: PPMMaxColorValue,
+		Transform:     MakeOutputTransform(),
+	}
+}
+
+// Write the contents of a canvas in the Portable Arbitrary Map (PAM) format
+// with red, green, blue, and alpha channels. PAM is the extension of the PPM
+// format that supports transparency. Colors are written unpremultiplied.
+func WriteCanvasToPAM(canvas Canvas, dest io.Writer, options PAMOptions) error {
+	if options.MaxColorValue < 1 || options.MaxColorValue > PPMLimitColorValue {
+		return fmt.Errorf("PAM max color value must be in the range [1, %d], got %d", PPMLimitColorValue, options.MaxColorValue)
+	}
+
+	writer := bufio.NewWriter(dest)
+
+	_, err := fmt.Fprintf(
+		writer,
+		"%s\nWIDTH %d\nHEIGHT %d\nDEPTH 4\nMAXVAL %d\nTUPLTYPE %s\nENDHDR\n",
+		PAMVersion,
+		canvas.Width,
+		canvas.Height,
+		options.MaxColorValue,
+		PAMTupleTypeRGBAlpha,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to write PAM header: %w", err)
+	}
+
+	colors := quantizeCanvasValues(canvas.Unpremultiplied(), options.Transform, options.MaxColorValue, options.Dither)
+	writeValue := func(value int) error {
+		if options.MaxColorValue > 255 {
+			if err := writer.WriteByte(byte(value >> 8)); err != nil {
+				return err
+			}
+		}
+
+		return writer.WriteByte(byte(value))
+	}
+
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			index := (y*canvas.Width + x) * 3
+			alpha := scaleToMaxValue(canvas.GetAlpha(x, y), options.MaxColorValue)
+
+			for _, value := range []int{colors[index], colors[index+1], colors[index+2], alpha} {
+				if err := writeValue(value); err != nil {
+					return fmt.Errorf("failed to write PAM body: %w", err)
+				}
+			}
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write PAM body: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCanvasToPAM(t *testing.T) {
+	source := MakeCanvas(2, 1)
+	source.SetPixel(0, 0, MakeColor(0.5, 0, 0.25))
+	source.SetAlpha(0, 0, 0.5)
+	source.SetPixel(1, 0, MakeColor(0, 1, 0))
+
+	want := []byte("P7\nWIDTH 2\nHEIGHT 1\nDEPTH 4\nMAXVAL 255\nTUPLTYPE RGB_ALPHA\nENDHDR\n")
+	want = append(want, 255, 0, 128, 128, 0, 255, 0, 255)
+
+	var buffer bytes.Buffer
+	if err := WriteCanvasToPAM(source, &buffer, MakePAMOptions()); err != nil {
+		t.Fatalf("WriteCanvasToPAM() returned error: %v", err)
+	}
+
+	if got := buffer.Bytes(); !bytes.Equal(want, got) {
+		t.Errorf("Expected contents %q, got %q", want, got)
+	}
+}
+
+func TestWriteCanvasToPAM_16Bit(t *testing.T) {
+	source := MakeCanvas(1, 1)
+	source.SetPixel(0, 0, MakeColor(1, 0, 0))
+
+	options := MakePAMOptions()
+	options.MaxColorValue = PPMLimitColorValue
+
+	var buffer bytes.Buffer
+	if err := WriteCanvasToPAM(source, &buffer, options); err != nil {
+		t.Fatalf("WriteCanvasToPAM() returned error: %v", err)
+	}
+
+	want := []byte{0xff, 0xff, 0, 0, 0, 0, 0xff, 0xff}
+	if got := buffer.Bytes()[buffer.Len()-len(want):]; !bytes.Equal(want, got) {
+		t.Errorf("Expected pixel data %v, got %v", want, got)
+	}
+}
+
+func TestWriteCanvasToPAM_InvalidMaxValue(t *testing.T) {
+	options := MakePAMOptions()
+	options.MaxColorValue = 0
+
+	var buffer bytes.Buffer
+	if err := WriteCanvasToPAM(MakeCanvas(1, 1), &buffer, options); err == nil {
+		t.Error("Expected an error for an invalid max color value")
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// A render pass is an additional image produced alongside the rendered colors,
+// filled from the primary hit of each camera ray. Passes are used to composite
+// and debug renders. Passes are bit flags so that several can be requested at
+// once by combining them with a bitwise or.
+type RenderPass int
+
+const (
+	// The t-value of the primary hit, stored in every channel. Camera rays
+	// have unit length, so this is the straight-line distance from the
+	// origin of the ray to the hit. For perspective cameras it grows towards
+	// the edges of the frame rather than measuring depth along the camera's
+	// viewing direction.
+	PassDepth RenderPass = 1 << iota
+	// The surface normal in world space, with the x, y, and z components
+	// stored in the red, green, and blue channels.
+	PassNormal
+	// The color of the hit object's material, without any lighting.
+	PassAlbedo
+	// The index of the hit object within the world plus one, stored in every
+	// channel. Pixels that hit nothing have an ID of zero.
+	PassObjectID
+	// The fraction of the pixel covered by objects.
+	PassMask
+)
+
+// Every render pass in the order they are listed.
+var allRenderPasses = []RenderPass{PassDepth, PassNormal, PassAlbedo, PassObjectID, PassMask}
+
+// Parse a comma separated list of render pass names as it would be given on
+// the command line. An empty list requests no passes.
+func ParseRenderPasses(names string) (RenderPass, error) {
+	var passes RenderPass
+	if names == "" {
+		return passes, nil
+	}
+
+	for _, name := range strings.Split(names, ",") {
+		found := false
+		for _, pass := range allRenderPasses {
+			if pass.String() == strings.TrimSpace(name) {
+				passes |= pass
+				found = true
+			}
+		}
+
+		if !found {
+			return 0, fmt.Errorf("unknown render pass '%s'", name)
+		}
+	}
+
+	return passes, nil
+}
+
+// Get the name of a single render pass.
+func (p RenderPass) String() string {
+	switch p {
+	case PassDepth:
+		return "depth"
+	case PassNormal:
+		return "normal"
+	case PassAlbedo:
+		return "albedo"
+	case PassObjectID:
+		return "object-id"
+	case PassMask:
+		return "mask"
+	}
+
+	return fmt.Sprintf("RenderPass(%d)", int(p))
+}
+
+// Split a combination of render passes into the individual passes.
+func (p RenderPass) Passes() []RenderPass {
+	var passes []RenderPass
+	for _, pass := range allRenderPasses {
+		if p&pass != 0 {
+			passes = append(passes, pass)
+		}
+	}
+
+	return passes
+}
+
+// Convert the raw values of a render pass into colors suitable for viewing in
+// an 8-bit image. Depths are scaled so the farthest hit is white, normals are
+// mapped from [-1, 1] to [0, 1], and each object ID is given a distinct color.
+// Albedo and mask passes are returned unchanged.
+func VisualizePass(pass RenderPass, canvas Canvas) Canvas {
+	visualized := MakeCanvas(canvas.Width, canvas.Height)
+
+	maxDepth := 0.0
+	if pass == PassDepth {
+		for y := 0; y < canvas.Height; y++ {
+			for x := 0; x < canvas.Width; x++ {
+				maxDepth = math.Max(maxDepth, canvas.GetPixel(x, y).Red())
+			}
+		}
+	}
+
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			color := canvas.GetPixel(x, y)
+
+			switch pass {
+			case PassDepth:
+				if maxDepth > 0 {
+					color = color.Multiply(1 / maxDepth)
+				}
+			case PassNormal:
+				color = color.Add(MakeColor(1, 1, 1)).Multiply(0.5)
+			case PassObjectID:
+				color = objectIDColor(int(math.Round(color.Red())))
+			}
+
+			visualized.SetPixel(x, y, color)
+		}
+	}
+
+	return visualized
+}
+
+// Get a distinct color for an object ID. Hues are spaced by the golden angle so
+// that objects with neighboring IDs have very different colors. The background
+// ID of zero is black.
+func objectIDColor(id int) Color {
+	if id == 0 {
+		return MakeColor(0, 0, 0)
+	}
+
+	hue := math.Mod(float64(id)*0.618033988749895, 1) * 6
+	channel := func(offset float64) float64 {
+		distance := math.Abs(math.Mod(hue+offset, 6) - 3)
+
+		return math.Max(0, math.Min(1, distance-1))
+	}
+
+	return MakeColor(channel(0), channel(4), channel(2))
+}
+
+// Buffers that accumulate the requested render passes. Depths, normals,
+// albedos, and masks are reconstructed with the render's filter like the
+// image's colors. Depths, normals, and albedos only average the samples that
+// hit an object so that edges do not blend with the background. Object IDs
+// cannot be blended, so each pixel keeps the ID of its sample nearest to the
+// pixel's center.
+type passBuffers struct {
+	passes RenderPass
+	width  int
+	height int
+
+	depth  film
+	normal film
+	albedo film
+	mask   film
+
+	// The object ID of each pixel and the distance of the sample it came from
+	// to the pixel's center.
+	objectIDs         []int
+	objectIDDistances []float64
+}
+
+func makePassBuffers(width, height int, passes RenderPass, filter Filter) *passBuffers {
+	buffers := passBuffers{passes: passes, width: width, height: height}
+
+	for _, pass := range passes.Passes() {
+		switch pass {
+		case PassDepth:
+			buffers.depth = makeFilm(width, height, filter)
+		case PassNormal:
+			buffers.normal = makeFilm(width, height, filter)
+		case PassAlbedo:
+			buffers.albedo = makeFilm(width, height, filter)
+		case PassMask:
+			buffers.mask = makeFilm(width, height, filter)
+		case PassObjectID:
+			buffers.objectIDs = make([]int, width*height)
+			buffers.objectIDDistances = make([]float64, width*height)
+			for i := range buffers.objectIDDistances {
+				buffers.objectIDDistances[i] = math.Inf(1)
+			}
+		}
+	}
+
+	return &buffers
+}
+
+// Add the primary hit of a sample taken at a continuous position on the film,
+// given in pixel units. If the sample missed every object, the computation is
+// ignored.
+func (b *passBuffers) AddSample(x, y float64, computation IntersectionComputation, hit bool) {
+	if b.passes&PassMask != 0 {
+		coverage := 0.0
+		if hit {
+			coverage = 1
+		}
+		b.mask.AddSample(x, y, MakeColor(coverage, coverage, coverage))
+	}
+
+	if b.passes&PassObjectID != 0 {
+		px := int(math.Min(float64(b.width-1), math.Max(0, math.Floor(x))))
+		py := int(math.Min(float64(b.height-1), math.Max(0, math.Floor(y))))
+		index := py*b.width + px
+
+		distance := math.Hypot(x-(float64(px)+0.5), y-(float64(py)+0.5))
+		if distance < b.objectIDDistances[index] {
+			b.objectIDDistances[index] = distance
+			b.objectIDs[index] = 0
+			if hit {
+				b.objectIDs[index] = computation.ObjectIndex + 1
+			}
+		}
+	}
+
+	if !hit {
+		return
+	}
+
+	if b.passes&PassDepth != 0 {
+		b.depth.AddSample(x, y, MakeColor(computation.T, computation.T, computation.T))
+	}
+
+	if b.passes&PassNormal != 0 {
+		normal := computation.NormalVector
+		b.normal.AddSample(x, y, MakeColor(normal.X, normal.Y, normal.Z))
+	}
+
+	if b.passes&PassAlbedo != 0 {
+		b.albedo.AddSample(x, y, computation.material().Color)
+	}
+}
+
+// Resolve the buffers into a canvas for each requested pass.
+func (b *passBuffers) Canvases() map[RenderPass]Canvas {
+	canvases := make(map[RenderPass]Canvas)
+
+	for _, pass := range b.passes.Passes() {
+		switch pass {
+		case PassDepth:
+			canvases[pass] = b.depth.Canvas()
+		case PassNormal:
+			canvases[pass] = b.normal.Canvas()
+		case PassAlbedo:
+			canvases[pass] = b.albedo.Canvas()
+		case PassMask:
+			canvases[pass] = b.mask.Canvas()
+		case PassObjectID:
+			canvas := MakeCanvas(b.width, b.height)
+			for y := 0; y < b.height; y++ {
+				for x := 0; x < b.width; x++ {
+					id := float64(b.objectIDs[y*b.width+x])
+					canvas.SetPixel(x, y, MakeColor(id, id, id))
+				}
+			}
+			canvases[pass] = canvas
+		}
+	}
+
+	return canvases
+}
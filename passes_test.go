@@ -0,0 +1,135 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseRenderPasses(t *testing.T) {
+	testCases := []struct {
+		names   string
+		want    RenderPass
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"depth", PassDepth, false},
+		{"normal,albedo", PassNormal | PassAlbedo, false},
+		{"object-id, mask", PassObjectID | PassMask, false},
+		{"depth,bogus", 0, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.names, func(t *testing.T) {
+			got, err := ParseRenderPasses(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error = %v, got %v", tt.wantErr, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Expected passes %v, got %v", tt.want.Passes(), got.Passes())
+			}
+		})
+	}
+}
+
+func TestRenderWithOptions_Passes(t *testing.T) {
+	world := MakeDefaultWorld()
+	camera := MakeCamera(11, 11, math.Pi/2)
+	camera.Transform = ViewTransform(
+		MakePoint(0, 0, -5),
+		MakePoint(0, 0, 0),
+		MakeVector(0, 1, 0),
+	)
+
+	testCases := []struct {
+		name     string
+		adaptive bool
+	}{
+		{"supersampled", false},
+		{"adaptive", true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			options := MakeRenderOptions()
+			options.Adaptive = tt.adaptive
+			options.Passes = PassDepth | PassNormal | PassAlbedo | PassObjectID | PassMask
+			passes := RenderWithOptions(camera, world, options).Passes
+
+			// The center of the image sees the front of the outer sphere and
+			// the corners see nothing.
+			want := map[RenderPass][2]Color{
+				PassDepth:    {MakeColor(4, 4, 4), MakeColor(0, 0, 0)},
+				PassNormal:   {MakeColor(0, 0, -1), MakeColor(0, 0, 0)},
+				PassAlbedo:   {MakeColor(0.8, 1, 0.6), MakeColor(0, 0, 0)},
+				PassObjectID: {MakeColor(1, 1, 1), MakeColor(0, 0, 0)},
+				PassMask:     {MakeColor(1, 1, 1), MakeColor(0, 0, 0)},
+			}
+
+			for pass, colors := range want {
+				canvas, ok := passes[pass]
+				if !ok {
+					t.Errorf("Expected a %v pass", pass)
+					continue
+				}
+
+				if got := canvas.GetPixel(5, 5); !colors[0].Equals(got) {
+					t.Errorf("Expected %v pass at (5, 5) to be %v, got %v", pass, colors[0], got)
+				}
+
+				if got := canvas.GetPixel(0, 0); !colors[1].Equals(got) {
+					t.Errorf("Expected %v pass at (0, 0) to be %v, got %v", pass, colors[1], got)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderWithOptions_NoPasses(t *testing.T) {
+	camera := MakeCamera(3, 3, math.Pi/2)
+	result := RenderWithOptions(camera, MakeDefaultWorld(), MakeRenderOptions())
+
+	if len(result.Passes) != 0 {
+		t.Errorf("Expected no passes, got %d", len(result.Passes))
+	}
+}
+
+func TestVisualizePass(t *testing.T) {
+	canvas := MakeCanvas(2, 1)
+	canvas.SetPixel(0, 0, MakeColor(-1, 0, 1))
+	canvas.SetPixel(1, 0, MakeColor(2, 2, 2))
+
+	testCases := []struct {
+		pass RenderPass
+		want [2]Color
+	}{
+		{PassDepth, [2]Color{MakeColor(-0.5, 0, 0.5), MakeColor(1, 1, 1)}},
+		{PassNormal, [2]Color{MakeColor(0, 0.5, 1), MakeColor(1.5, 1.5, 1.5)}},
+		{PassAlbedo, [2]Color{MakeColor(-1, 0, 1), MakeColor(2, 2, 2)}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.pass.String(), func(t *testing.T) {
+			got := VisualizePass(tt.pass, canvas)
+
+			for x, want := range tt.want {
+				if color := got.GetPixel(x, 0); !want.Equals(color) {
+					t.Errorf("Expected pixel %d to be %v, got %v", x, want, color)
+				}
+			}
+		})
+	}
+}
+
+func TestObjectIDColor(t *testing.T) {
+	if got := objectIDColor(0); !got.Equals(MakeColor(0, 0, 0)) {
+		t.Errorf("Expected the background to be black, got %v", got)
+	}
+
+	seen := make(map[Color]int)
+	for id := 1; id <= 8; id++ {
+		color := objectIDColor(id)
+		if other, ok := seen[color]; ok {
+			t.Errorf("Expected objects %d and %d to have different colors, both got %v", other, id, color)
+		}
+
+		seen[color] = id
+	}
+}
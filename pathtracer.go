@@ -0,0 +1,263 @@
+package main
+
+import "math"
+
+// The path integrator is a physically based Monte Carlo path tracer. It
+// computes the color seen along a ray by tracing a random path of light through
+// the world. At every surface the path hits, light arriving directly from the
+// world's light source is added, and the path continues in a random direction
+// to gather light that bounced off of other surfaces. Averaging many paths
+// through each pixel produces soft indirect lighting and color bleeding
+// without relying on the ambient term of materials.
+//
+// Surfaces scatter light according to the BSDF of their material. Materials
+// without a BSDF are treated as perfectly diffuse, reflecting the fraction
+// Color × Diffuse of the light reaching them. Point lights deliver the same
+// irradiance as they do in Lighting, without falloff over distance, so direct
+// lighting matches the diffuse term of the Phong model. Paths that miss every
+// object gather light from the world's environment.
+//
+// Emissive objects light the world like the light source does. Emissive
+// objects that are sampled surfaces are sampled directly at every surface a
+// path hits. The light they give off is otherwise only added when it is seen
+// directly or through mirrors and glass, so that it is not counted twice.
+//
+// Sampled environments are also sampled directly at every surface. Light from
+// them is found both by sampling the environment and by paths that escape,
+// and the two are combined with multiple importance sampling, which favors
+// whichever was more likely to find the light. Sampling the environment
+// finds small, bright lights like the sun, while following the BSDF finds the
+// light reflected by shiny surfaces.
+type PathIntegrator struct {
+	// The maximum number of surfaces a path may bounce off of.
+	MaxBounces int
+}
+
+// Create a path integrator that follows up to eight bounces.
+func MakePathIntegrator() PathIntegrator {
+	return PathIntegrator{MaxBounces: 8}
+}
+
+// Compute the color seen along a ray.
+func (i PathIntegrator) Radiance(ray Ray, world World, depth int, sampler Sampler) Color {
+	computation, hit := world.primaryHit(ray)
+
+	return i.tracePath(ray, world, computation, hit, depth, sampler)
+}
+
+// Compute the color seen along a ray from the camera with a known hit.
+func (i PathIntegrator) radianceForHit(ray Ray, world World, computation IntersectionComputation, hit bool, sampler Sampler) Color {
+	return i.tracePath(ray, world, computation, hit, 0, sampler)
+}
+
+// Follow a path of light backwards from a ray whose nearest hit has already
+// been found.
+func (i PathIntegrator) tracePath(ray Ray, world World, computation IntersectionComputation, hit bool, depth int, sampler Sampler) Color {
+	radiance := MakeColor(0, 0, 0)
+	throughput := MakeColor(1, 1, 1)
+	// Whether light given off by the next surface the path hits has not
+	// already been counted by sampling the light directly.
+	countEmission := true
+	// The probability density of the BSDF choosing the path's direction.
+	directionPDF := 0.0
+
+	for bounce := depth; ; bounce++ {
+		if bounce > depth {
+			computation, hit = world.primaryHit(ray)
+		}
+		if !hit {
+			environment := world.environmentColor(ray)
+			if sampled, ok := world.Environment.(SampledEnvironment); ok && !countEmission {
+				environment = environment.Multiply(powerHeuristic(directionPDF, sampled.PDF(ray.Direction)))
+			}
+
+			return radiance.Add(throughput.Blend(environment))
+		}
+
+		material := computation.material()
+		if countEmission || !isSampledLight(computation.Object) {
+			radiance = radiance.Add(throughput.Blend(material.emitted()))
+		}
+
+		// Next event estimation: add the light arriving straight from the
+		// light sources instead of waiting for a path to find them.
+		radiance = radiance.Add(throughput.Blend(world.directLighting(computation, material, ray.Time)))
+		radiance = radiance.Add(throughput.Blend(world.sunLighting(computation, material, ray.Time)))
+		radiance = radiance.Add(throughput.Blend(world.emissiveLighting(computation, material, ray.Time, sampler)))
+		last := bounce+1 >= i.MaxBounces
+		radiance = radiance.Add(throughput.Blend(world.environmentLighting(computation, material, ray.Time, sampler, !last)))
+
+		if last {
+			return radiance
+		}
+
+		// Continue the path in a direction chosen by the material's BSDF.
+		bsdf := material.scattering()
+		direction, weight, ok := bsdf.Sample(computation.surfaceNormal(), computation.EyeVector, sampler)
+		if !ok {
+			return radiance
+		}
+		throughput = throughput.Blend(weight)
+
+		// Mirrors and glass cannot be lit by sampling lights, so the light
+		// they reflect from emissive objects is found by the path instead.
+		directionPDF = bsdf.PDF(computation.surfaceNormal(), computation.EyeVector, direction)
+		countEmission = directionPDF == 0
+
+		// Russian roulette: after a few bounces, randomly end paths that
+		// carry little light and boost the survivors to make up for it.
+		if bounce >= pathRouletteDepth {
+			survival := math.Min(0.95, math.Max(throughput.Red(), math.Max(throughput.Green(), throughput.Blue())))
+			if sampler.Float64() >= survival {
+				return radiance
+			}
+
+			throughput = throughput.Multiply(1 / survival)
+		}
+
+		// Paths refracted through the surface continue from its other side.
+		origin := computation.OverPoint
+		if direction.Dot(computation.NormalVector) < 0 {
+			origin = computation.UnderPoint
+		}
+
+		ray = MakeRayAtTime(origin, direction, ray.Time)
+	}
+}
+
+// The number of bounces a path makes before it may be ended by Russian
+// roulette.
+const pathRouletteDepth = 3
+
+// Compute the light reflected towards the eye by a surface from the world's
+// light source. Surfaces in shadow receive no direct light.
+func (w World) directLighting(computation IntersectionComputation, material Material, time float64) Color {
+	light := w.Light
+	toLight := light.Position.Subtract(computation.OverPoint).Normalized()
+
+	// Worlds lit only by emissive objects have a black light source, which
+	// doesn't need a shadow ray.
+	cosine := toLight.Dot(computation.NormalVector)
+	if cosine <= 0 || light.Intensity.Equals(MakeColor(0, 0, 0)) ||
+		w.isOccluded(computation.OverPoint, light.Position, time) {
+		return MakeColor(0, 0, 0)
+	}
+
+	// Point lights deliver pi times the irradiance the BSDF is defined for,
+	// matching Lighting.
+	bsdf := material.scattering()
+	reflectance := bsdf.Evaluate(computation.surfaceNormal(), computation.EyeVector, toLight)
+
+	return reflectance.Blend(light.Intensity).Multiply(math.Pi * cosine)
+}
+
+// Compute the light reflected towards the eye by a surface from the world's
+// directional light. Surfaces with an object between them and the light are in
+// shadow.
+func (w World) sunLighting(computation IntersectionComputation, material Material, time float64) Color {
+	sun := w.Sun
+	cosine := sun.Direction.Dot(computation.NormalVector)
+	if !sun.IsOn() || cosine <= 0 {
+		return MakeColor(0, 0, 0)
+	}
+
+	if _, blocked := w.intersect(MakeRayAtTime(computation.OverPoint, sun.Direction, time)).Hit(); blocked {
+		return MakeColor(0, 0, 0)
+	}
+
+	bsdf := material.scattering()
+	reflectance := bsdf.Evaluate(computation.surfaceNormal(), computation.EyeVector, sun.Direction)
+
+	return reflectance.Blend(sun.Intensity).Multiply(math.Pi * cosine)
+}
+
+// Compute the light reflected towards the eye by a surface from the emissive
+// objects in the world that are sampled surfaces. A random point is chosen on
+// each of them, and the light it gives off is added if the point is visible.
+func (w World) emissiveLighting(computation IntersectionComputation, material Material, time float64, sampler Sampler) Color {
+	total := MakeColor(0, 0, 0)
+	bsdf := material.scattering()
+
+	for _, object := range w.Objects {
+		if !isSampledLight(object) {
+			continue
+		}
+
+		point, normal, pdf := object.(SampledSurface).SampleSurface(sampler.Float64(), sampler.Float64(), time)
+		toLight := point.Subtract(computation.OverPoint)
+		distanceSquared := toLight.Dot(toLight)
+		direction := toLight.Normalized()
+
+		cosine := direction.Dot(computation.NormalVector)
+		cosLight := math.Abs(direction.Dot(normal))
+		if cosine <= 0 || cosLight <= 0 || pdf <= 0 {
+			continue
+		}
+
+		// Stop the shadow ray just short of the light's surface so that the
+		// light does not shadow itself.
+		target := point.Add(faceForward(normal, direction.Negate()).Multiply(floatEpsilon))
+		if w.isOccluded(computation.OverPoint, target, time) {
+			continue
+		}
+
+		// Convert the density of the point per unit of area to the density
+		// of the direction per unit of solid angle.
+		solidAnglePDF := pdf * distanceSquared / cosLight
+		reflectance := bsdf.Evaluate(computation.surfaceNormal(), computation.EyeVector, direction)
+		total = total.Add(reflectance.Blend(object.Material().emitted()).Multiply(cosine / solidAnglePDF))
+	}
+
+	return total
+}
+
+// Determine if an object is sampled as a light source.
+func isSampledLight(object Object) bool {
+	_, ok := object.(SampledSurface)
+
+	return ok && object.Material().isEmissive()
+}
+
+// Compute the light reflected towards the eye by a surface from the world's
+// environment, if it is a sampled environment. A random direction is chosen
+// from the environment, and its light is added if nothing blocks it. If the
+// path continues, and so may find the same light, the light is weighted
+// against the chance of that happening.
+func (w World) environmentLighting(computation IntersectionComputation, material Material, time float64, sampler Sampler, continues bool) Color {
+	environment, ok := w.Environment.(SampledEnvironment)
+	if !ok {
+		return MakeColor(0, 0, 0)
+	}
+
+	direction, color, pdf := environment.SampleDirection(sampler.Float64(), sampler.Float64())
+	cosine := direction.Dot(computation.NormalVector)
+	if pdf <= 0 || cosine <= 0 {
+		return MakeColor(0, 0, 0)
+	}
+
+	if _, blocked := w.intersect(MakeRayAtTime(computation.OverPoint, direction, time)).Hit(); blocked {
+		return MakeColor(0, 0, 0)
+	}
+
+	bsdf := material.scattering()
+	normal := computation.surfaceNormal()
+	reflectance := bsdf.Evaluate(normal, computation.EyeVector, direction)
+	weight := 1.0
+	if continues {
+		weight = powerHeuristic(pdf, bsdf.PDF(normal, computation.EyeVector, direction))
+	}
+
+	return reflectance.Blend(color).Multiply(cosine * weight / pdf)
+}
+
+// Get the weight of a sample chosen with one strategy when the same sample
+// could have been chosen by another, using the power heuristic of multiple
+// importance sampling. The probability densities of the chosen and other
+// strategy choosing the sample are given.
+func powerHeuristic(chosen, other float64) float64 {
+	if chosen <= 0 {
+		return 0
+	}
+
+	return chosen * chosen / (chosen*chosen + other*other)
+}
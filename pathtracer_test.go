@@ -0,0 +1,276 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// A diffuse sphere surrounded by a uniformly white environment reflects the
+// fraction of light given by its albedo. Every bounce off of a convex object
+// escapes to the environment, so each path gives the exact answer.
+func TestPathIntegrator_Radiance_Furnace(t *testing.T) {
+	testCases := []struct {
+		name    string
+		diffuse float64
+	}{
+		{"white", 1},
+		{"gray", 0.5},
+		{"black", 0},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sphere := MakeSphere()
+			sphere.material.Diffuse = tt.diffuse
+
+			world := MakeWorld()
+			world.Objects = []Object{sphere}
+			world.Environment = MakeConstantEnvironment(MakeColor(1, 1, 1))
+
+			random := rand.New(rand.NewSource(0))
+			want := MakeColor(tt.diffuse, tt.diffuse, tt.diffuse)
+			for i := 0; i < 100; i++ {
+				ray := MakeRay(MakePoint(0, 0, -5), MakeVector(random.Float64()*0.1, random.Float64()*0.1, 1).Normalized())
+				got := MakePathIntegrator().Radiance(ray, world, 0, random)
+				if !want.Equals(got) {
+					t.Fatalf("Expected color %v, got %v", want, got)
+				}
+			}
+		})
+	}
+}
+
+// Without an environment or other objects to bounce light, path tracing gives
+// the diffuse term of the Phong model.
+func TestPathIntegrator_Radiance_DirectLighting(t *testing.T) {
+	sphere := MakeSphere()
+	sphere.material.Color = MakeColor(0.8, 1, 0.6)
+	sphere.material.Diffuse = 0.7
+
+	world := MakeWorld()
+	world.Light = MakePointLight(MakePoint(-10, 10, -10), MakeColor(1, 1, 1))
+	world.Objects = []Object{sphere}
+
+	ray := MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1))
+	computation := MakeIntersection(4, sphere).PrepareComputations(ray)
+
+	phong := sphere.material
+	phong.Ambient = 0
+	phong.Specular = 0
+	want := Lighting(phong, world.Light, computation.Point, computation.EyeVector, computation.NormalVector)
+
+	got := MakePathIntegrator().Radiance(ray, world, 0, rand.New(rand.NewSource(0)))
+	if !want.Equals(got) {
+		t.Errorf("Expected color %v, got %v", want, got)
+	}
+}
+
+func TestPathIntegrator_Radiance_Shadow(t *testing.T) {
+	world := MakeWorld()
+	world.Light = MakePointLight(MakePoint(0, 10, 0), MakeColor(1, 1, 1))
+	world.Objects = []Object{
+		MakeSphere(),
+		MakeSphereTransformed(MakeTranslation(0, 5, 0)),
+	}
+
+	// The top of the lower sphere is blocked from the light by the upper
+	// sphere, and the paths bouncing off of it can only reach the unlit
+	// underside of the upper sphere or the black void.
+	ray := MakeRay(MakePoint(0, 2, 0), MakeVector(0, -1, 0))
+	got := PathIntegrator{MaxBounces: 1}.Radiance(ray, world, 0, rand.New(rand.NewSource(0)))
+
+	if want := MakeColor(0, 0, 0); !want.Equals(got) {
+		t.Errorf("Expected color %v, got %v", want, got)
+	}
+}
+
+func TestPathIntegrator_Radiance_Sun(t *testing.T) {
+	testCases := []struct {
+		name    string
+		blocker bool
+		want    Color
+	}{
+		// A white surface facing the sun reflects the sun's intensity.
+		{"lit", false, MakeColor(1, 0.9, 0.8)},
+		{"shadowed", true, MakeColor(0, 0, 0)},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sphere := MakeSphere()
+			sphere.material.Diffuse = 1
+
+			world := MakeWorld()
+			world.Light.Intensity = MakeColor(0, 0, 0)
+			world.Sun = MakeDirectionalLight(MakeVector(0, 1, 0), MakeColor(1, 0.9, 0.8))
+			world.Objects = []Object{sphere}
+			if tt.blocker {
+				world.Objects = append(world.Objects, MakeSphereTransformed(MakeTranslation(0, 5, 0)))
+			}
+
+			ray := MakeRay(MakePoint(0, 2, 0), MakeVector(0, -1, 0))
+			got := PathIntegrator{MaxBounces: 1}.Radiance(ray, world, 0, rand.New(rand.NewSource(0)))
+
+			if !tt.want.Equals(got) {
+				t.Errorf("Expected color %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// Light bouncing off of a colored surface tints the surfaces around it.
+func TestRenderWithOptions_PathTracingColorBleeding(t *testing.T) {
+	floor := MakeSphereTransformed(MakeScale(10, 0.01, 10))
+	floor.material.Diffuse = 1
+
+	wall := MakeSphereTransformed(MakeTranslation(0.3, 1, 0).Multiply(MakeScale(0.01, 1, 1)))
+	wall.material.Color = MakeColor(1, 0, 0)
+	wall.material.Diffuse = 1
+
+	world := MakeWorld()
+	world.Light = MakePointLight(MakePoint(-5, 5, 0), MakeColor(1, 1, 1))
+	world.Objects = []Object{floor, wall}
+
+	camera := MakeCamera(1, 1, 0.01)
+	camera.Transform = ViewTransform(MakePoint(-1, 3, 0), MakePoint(0, 0, 0), MakeVector(0, 1, 0))
+
+	options := MakeRenderOptions()
+	options.Integrator = MakePathIntegrator()
+	options.SamplesPerPixel = 256
+	options.SamplePattern = SampleJittered
+	options.Seed = 1
+
+	got := RenderWithOptions(camera, world, options).Canvas.GetPixel(0, 0)
+
+	if !(got.Red() > got.Green()+0.01) || !Float64Equal(got.Green(), got.Blue()) {
+		t.Errorf("Expected the floor next to the red wall to be tinted red, got %v", got)
+	}
+
+	options.Integrator = WhittedIntegrator{}
+	if whitted := RenderWithOptions(camera, world, options).Canvas.GetPixel(0, 0); math.Abs(whitted.Red()-whitted.Green()) > floatEpsilon {
+		t.Errorf("Expected Phong shading to ignore bounce light, got %v", whitted)
+	}
+}
+
+// Microfacet surfaces in a uniformly white environment never reflect more
+// light than they receive, and smooth metals reflect nearly all of it.
+func TestPathIntegrator_Radiance_MicrofacetFurnace(t *testing.T) {
+	testCases := []struct {
+		name      string
+		metallic  float64
+		roughness float64
+		minimum   float64
+	}{
+		{"smooth metal", 1, 0, 0.99},
+		{"rough metal", 1, 0.8, 0.4},
+		{"plastic", 0, 0.4, 0.7},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sphere := MakeSphere()
+			sphere.material = MakeMicrofacetMaterial(MakeColor(1, 1, 1), tt.metallic, tt.roughness)
+
+			world := MakeWorld()
+			world.Objects = []Object{sphere}
+			world.Environment = MakeConstantEnvironment(MakeColor(1, 1, 1))
+
+			random := rand.New(rand.NewSource(0))
+			samples := 2000
+			total := 0.0
+			for i := 0; i < samples; i++ {
+				ray := MakeRay(MakePoint(0, 0, -5), MakeVector(random.Float64()*0.1, random.Float64()*0.1, 1).Normalized())
+				total += MakePathIntegrator().Radiance(ray, world, 0, random).Red()
+			}
+			average := total / float64(samples)
+
+			if average > 1.01 || average < tt.minimum {
+				t.Errorf("Expected between %v and 1 of the light to be reflected, got %v", tt.minimum, average)
+			}
+		})
+	}
+}
+
+// Clear glass and perfect mirrors in a uniformly white environment pass on
+// all of the light reaching them, however many times paths bounce inside.
+// Russian roulette makes individual paths brighter or darker, but not their
+// average.
+func TestPathIntegrator_Radiance_SpecularFurnace(t *testing.T) {
+	testCases := []struct {
+		name string
+		bsdf BSDF
+	}{
+		{"mirror", MirrorBSDF{Color: MakeColor(1, 1, 1)}},
+		{"glass", DielectricBSDF{IndexOfRefraction: 1.5, Color: MakeColor(1, 1, 1)}},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sphere := MakeSphere()
+			sphere.material = MakeBSDFMaterial(MakeColor(1, 1, 1), tt.bsdf)
+
+			world := MakeWorld()
+			world.Objects = []Object{sphere}
+			world.Environment = MakeConstantEnvironment(MakeColor(1, 1, 1))
+
+			random := rand.New(rand.NewSource(0))
+			samples := 5000
+			total := 0.0
+			for i := 0; i < samples; i++ {
+				ray := MakeRay(MakePoint(0, 0, -5), MakeVector(random.Float64()*0.2, random.Float64()*0.2, 1).Normalized())
+				total += PathIntegrator{MaxBounces: 64}.Radiance(ray, world, 0, random).Red()
+			}
+
+			if average := total / float64(samples); math.Abs(average-1) > 0.01 {
+				t.Errorf("Expected all of the light to pass through, got %v", average)
+			}
+		})
+	}
+}
+
+func TestPathIntegrator_Radiance_Emission(t *testing.T) {
+	// A light with a radius of 1 hangs 4 units above the top of a diffuse
+	// sphere. Seen head on, a spherical light covers the same solid angle as
+	// a disk lit evenly, so the top of the sphere receives pi L (r / d)^2.
+	light := MakeSphereTransformed(MakeTranslation(0, 5, 0))
+	light.material = MakeEmissiveMaterial(MakeColor(1, 0.5, 0.25), 4)
+
+	floor := MakeSphere()
+	floor.material.Diffuse = 0.5
+
+	world := MakeWorld()
+	world.Light = MakePointLight(MakePoint(0, 0, 0), MakeColor(0, 0, 0))
+	world.Objects = []Object{floor, light}
+
+	testCases := []struct {
+		name string
+		ray  Ray
+		want Color
+	}{
+		{
+			"seen directly",
+			MakeRay(MakePoint(0, 5, -5), MakeVector(0, 0, 1)),
+			MakeColor(4, 2, 1),
+		},
+		{
+			"lighting a diffuse surface",
+			MakeRay(MakePoint(0, 3, -2), MakeVector(0, -2, 2).Normalized()),
+			MakeColor(4, 2, 1).Multiply(0.5 / 16),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			random := rand.New(rand.NewSource(0))
+			samples := 20000
+			total := MakeColor(0, 0, 0)
+			for i := 0; i < samples; i++ {
+				total = total.Add(PathIntegrator{MaxBounces: 1}.Radiance(tt.ray, world, 0, random))
+			}
+			average := total.Multiply(1 / float64(samples))
+
+			diff := average.Subtract(tt.want)
+			for _, channel := range []float64{diff.Red(), diff.Green(), diff.Blue()} {
+				if math.Abs(channel) > 0.03*tt.want.Red() {
+					t.Fatalf("Expected color %v, got %v", tt.want, average)
+				}
+			}
+		})
+	}
+}
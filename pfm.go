@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+const (
+	// The header identifying a Portable Float Map with three color channels.
+	PFMColorVersion = "PF"
+	// The header identifying a Portable Float Map with a single channel.
+	PFMGrayscaleVersion = "Pf"
+)
+
+// Write the contents of a canvas as a Portable Float Map. Each channel is
+// stored as a 32-bit float, so intensities outside the range [0, 1] are
+// preserved.
+func WriteCanvasToPFM(canvas Canvas, dest io.Writer) error {
+	// A negative scale indicates that values are stored little-endian.
+	header := PFMColorVersion +
+		"\n" +
+		strconv.Itoa(canvas.Width) +
+		" " +
+		strconv.Itoa(canvas.Height) +
+		"\n" +
+		"-1.0\n"
+
+	if _, err := dest.Write([]byte(header)); err != nil {
+		return fmt.Errorf("failed to write PFM header: %w", err)
+	}
+
+	// Rows are stored from the bottom of the image to the top.
+	row := make([]byte, canvas.Width*3*4)
+	for y := canvas.Height - 1; y >= 0; y-- {
+		for x := 0; x < canvas.Width; x++ {
+			color := canvas.GetPixel(x, y)
+
+			for i, value := range []float64{color.Red(), color.Green(), color.Blue()} {
+				offset := (x*3 + i) * 4
+				binary.LittleEndian.PutUint32(row[offset:], math.Float32bits(float32(value)))
+			}
+		}
+
+		if _, err := dest.Write(row); err != nil {
+			return fmt.Errorf("failed to write PFM body: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Read a Portable Float Map into a canvas. Grayscale maps produce a canvas
+// where every channel has the same value.
+func ReadPFM(source io.Reader) (Canvas, error) {
+	reader := bufio.NewReader(source)
+
+	version, err := readPPMToken(reader)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("failed to read PFM version: %w", err)
+	}
+
+	channels := 3
+	switch version {
+	case PFMColorVersion:
+	case PFMGrayscaleVersion:
+		channels = 1
+	default:
+		return Canvas{}, fmt.Errorf("unsupported PFM version '%s'", version)
+	}
+
+	width, err := readPPMInt(reader)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("failed to read PFM width: %w", err)
+	}
+
+	height, err := readPPMInt(reader)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("failed to read PFM height: %w", err)
+	}
+
+	if width < 1 || height < 1 {
+		return Canvas{}, fmt.Errorf("invalid PFM dimensions %dx%d", width, height)
+	}
+
+	scaleToken, err := readPPMToken(reader)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("failed to read PFM scale: %w", err)
+	}
+
+	scale, err := strconv.ParseFloat(scaleToken, 64)
+	if err != nil || scale == 0 {
+		return Canvas{}, fmt.Errorf("invalid PFM scale '%s'", scaleToken)
+	}
+
+	var byteOrder binary.ByteOrder = binary.BigEndian
+	if scale < 0 {
+		byteOrder = binary.LittleEndian
+	}
+
+	canvas := MakeCanvas(width, height)
+	row := make([]byte, width*channels*4)
+	for y := height - 1; y >= 0; y-- {
+		if _, err := io.ReadFull(reader, row); err != nil {
+			return Canvas{}, fmt.Errorf("failed to read PFM row %d: %w", y, err)
+		}
+
+		for x := 0; x < width; x++ {
+			var values [3]float64
+			for i := 0; i < channels; i++ {
+				offset := (x*channels + i) * 4
+				values[i] = float64(math.Float32frombits(byteOrder.Uint32(row[offset:])))
+			}
+
+			if channels == 1 {
+				values[1], values[2] = values[0], values[0]
+			}
+
+			canvas.SetPixel(x, y, MakeColor(values[0], values[1], values[2]))
+		}
+	}
+
+	return canvas, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriteCanvasToPFM(t *testing.T) {
+	source := MakeCanvas(1, 2)
+	source.SetPixel(0, 0, MakeColor(1, 2, 3))
+	source.SetPixel(0, 1, MakeColor(-1, 0.5, 100))
+
+	var buffer bytes.Buffer
+	if err := WriteCanvasToPFM(source, &buffer); err != nil {
+		t.Fatalf("WriteCanvasToPFM() returned error: %v", err)
+	}
+
+	header := "PF\n1 2\n-1.0\n"
+	if got := buffer.String()[:len(header)]; got != header {
+		t.Fatalf("Expected header %#v, got %#v", header, got)
+	}
+
+	// The bottom row is written first.
+	body := buffer.Bytes()[len(header):]
+	want := []float32{-1, 0.5, 100, 1, 2, 3}
+	if len(body) != len(want)*4 {
+		t.Fatalf("Expected %d bytes of pixel data, got %d", len(want)*4, len(body))
+	}
+
+	for i, value := range want {
+		if got := math.Float32frombits(binary.LittleEndian.Uint32(body[i*4:])); got != value {
+			t.Errorf("Expected value %d to be %v, got %v", i, value, got)
+		}
+	}
+}
+
+func TestReadPFM(t *testing.T) {
+	bigEndian := func(values ...float32) string {
+		var buffer bytes.Buffer
+		for _, value := range values {
+			_ = binary.Write(&buffer, binary.BigEndian, value)
+		}
+
+		return buffer.String()
+	}
+
+	testCases := []struct {
+		name     string
+		contents string
+		want     []Color
+	}{
+		{
+			"big-endian color",
+			"PF\n2 1\n1.0\n" + bigEndian(1, 2, 3, 4, 5, 6),
+			[]Color{MakeColor(1, 2, 3), MakeColor(4, 5, 6)},
+		},
+		{
+			"big-endian grayscale",
+			"Pf\n2 1\n1.0\n" + bigEndian(0.25, 7),
+			[]Color{MakeColor(0.25, 0.25, 0.25), MakeColor(7, 7, 7)},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			canvas, err := ReadPFM(strings.NewReader(tt.contents))
+			if err != nil {
+				t.Fatalf("ReadPFM() returned error: %v", err)
+			}
+
+			for x, want := range tt.want {
+				if got := canvas.GetPixel(x, 0); !want.Equals(got) {
+					t.Errorf("Expected pixel (%d, 0) to be %v, got %v", x, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestReadPFM_RoundTrip(t *testing.T) {
+	source := MakeCanvas(3, 2)
+	source.SetPixel(0, 0, MakeColor(12.5, -3, 0.001))
+	source.SetPixel(2, 1, MakeColor(1000, 0.5, 0.25))
+
+	var buffer bytes.Buffer
+	if err := WriteCanvasToPFM(source, &buffer); err != nil {
+		t.Fatalf("WriteCanvasToPFM() returned error: %v", err)
+	}
+
+	got, err := ReadPFM(&buffer)
+	if err != nil {
+		t.Fatalf("ReadPFM() returned error: %v", err)
+	}
+
+	for y := 0; y < source.Height; y++ {
+		for x := 0; x < source.Width; x++ {
+			if want, got := source.GetPixel(x, y), got.GetPixel(x, y); !want.Equals(got) {
+				t.Errorf("Expected pixel (%d, %d) to be %v, got %v", x, y, want, got)
+			}
+		}
+	}
+}
+
+func TestReadPFM_Errors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+	}{
+		{"wrong version", "P6\n1 1\n-1.0\n"},
+		{"bad scale", "PF\n1 1\nbig\n"},
+		{"zero scale", "PF\n1 1\n0\n"},
+		{"truncated", "PF\n1 1\n-1.0\n\x00\x00\x00\x00"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadPFM(strings.NewReader(tt.contents)); err == nil {
+				t.Error("Expected ReadPFM() to return an error")
+			}
+		})
+	}
+}
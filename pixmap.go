@@ -9,35 +9,99 @@ import (
 
 const (
 	PPMVersion       = "P3"
+	PPMBinaryVersion = "P6"
 	PPMMaxColorValue = 255
 	PPMMaxLineLength = 70
+
+	// The largest maximum color value allowed by the PPM format.
+	PPMLimitColorValue = 65535
+)
+
+// The encoding used for the pixel data of a PPM file.
+type PPMFormat int
+
+const (
+	// Pixel values are written as human readable decimal numbers. This is
+	// the "P3" format.
+	PPMASCII PPMFormat = iota
+	// Pixel values are written as raw bytes. This is the "P6" format.
+	PPMBinary
 )
 
+// Options controlling how a canvas is written as a PPM.
+type PPMOptions struct {
+	// The encoding of the pixel data.
+	Format PPMFormat
+	// The value representing full intensity. Values above 255 use two bytes per
+	// value in the binary format. Must be in the range [1, 65535].
+	MaxColorValue int
+	// The transform applied to colors before they are scaled to the color
+	// range.
+	Transform OutputTransform
+	// The dithering applied while quantizing colors.
+	Dither Dither
+}
+
+// Create PPM options that write an ASCII PPM with 8-bit color values. Colors
+// are written linearly.
+func MakePPMOptions() PPMOptions {
+	return PPMOptions{
+		Format:        PPMASCII,
+		MaxColorValue: PPMMaxColorValue,
+		Transform:     MakeOutputTransform(),
+	}
+}
+
 // Write the contents of a canvas in PPM format.
 func WriteCanvasToPPM(canvas Canvas, dest io.Writer) error {
-	if err := writePPMHeader(canvas, dest); err != nil {
+	return WriteCanvasToPPMWithOptions(canvas, dest, MakePPMOptions())
+}
+
+// Write the contents of a canvas in PPM format using the given options.
+func WriteCanvasToPPMWithOptions(canvas Canvas, dest io.Writer, options PPMOptions) error {
+	if options.MaxColorValue < 1 || options.MaxColorValue > PPMLimitColorValue {
+		return fmt.Errorf("PPM max color value must be in the range [1, %d], got %d", PPMLimitColorValue, options.MaxColorValue)
+	}
+
+	version := PPMVersion
+	writeBody := writePPMASCIIBody
+	if options.Format == PPMBinary {
+		version = PPMBinaryVersion
+		writeBody = writePPMBinaryBody
+	}
+
+	if err := writePPMHeaderWithVersion(canvas, dest, version, options.MaxColorValue); err != nil {
 		return fmt.Errorf("failed to write PPM header: %w", err)
 	}
 
-	if err := writePPMBody(canvas, dest); err != nil {
+	if err := writeBody(canvas, dest, options); err != nil {
 		return fmt.Errorf("failed to write PPM body: %w", err)
 	}
 
 	return nil
 }
 
-// Write the individual pixel data to a destination. Each pixel from the source
-// canvas is scaled so that the RGB values are integers in the PPM's color range
-// rather than floats in the range [0, 1].
+// Write the individual pixel data to a destination in the ASCII format with
+// 8-bit color values.
 func writePPMBody(source Canvas, dest io.Writer) error {
+	return writePPMASCIIBody(source, dest, MakePPMOptions())
+}
+
+// Write the individual pixel data to a destination as decimal numbers. Each
+// pixel from the source canvas is transformed and quantized so that the RGB
+// values are integers in the range [0, max color value] rather than floats in
+// the range [0, 1].
+func writePPMASCIIBody(source Canvas, dest io.Writer, options PPMOptions) error {
+	values := quantizeCanvasValues(source, options.Transform, options.MaxColorValue, options.Dither)
+
 	for y := 0; y < source.Height; y++ {
 		lineLength := 0
 
 		for x := 0; x < source.Width; x++ {
-			color := source.GetPixel(x, y)
+			index := (y*source.Width + x) * 3
 
-			for _, value := range []float64{color.Red(), color.Green(), color.Blue()} {
-				valueString := strconv.Itoa(scaleToPPMValue(value))
+			for _, value := range values[index : index+3] {
+				valueString := strconv.Itoa(value)
 				valueLength := len(valueString)
 				if lineLength != 0 {
 					// Include separator
@@ -84,16 +148,51 @@ func writePPMBody(source Canvas, dest io.Writer) error {
 	return nil
 }
 
+// Write the individual pixel data to a destination as raw bytes. Each value
+// takes a single byte if the maximum value is less than 256, and two bytes with
+// the most significant byte first otherwise.
+func writePPMBinaryBody(source Canvas, dest io.Writer, options PPMOptions) error {
+	bytesPerValue := 1
+	if options.MaxColorValue > 255 {
+		bytesPerValue = 2
+	}
+
+	values := quantizeCanvasValues(source, options.Transform, options.MaxColorValue, options.Dither)
+
+	row := make([]byte, 0, source.Width*3*bytesPerValue)
+	for y := 0; y < source.Height; y++ {
+		row = row[:0]
+
+		for _, value := range values[y*source.Width*3 : (y+1)*source.Width*3] {
+			if bytesPerValue == 2 {
+				row = append(row, byte(value>>8))
+			}
+			row = append(row, byte(value))
+		}
+
+		if _, err := dest.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write the header of an ASCII PPM file with 8-bit color values.
+func writePPMHeader(canvas Canvas, dest io.Writer) error {
+	return writePPMHeaderWithVersion(canvas, dest, PPMVersion, PPMMaxColorValue)
+}
+
 // Write the header of the PPM file. The header includes the PPM version string,
 // the dimensions of the image, and the maximum color value.
-func writePPMHeader(canvas Canvas, dest io.Writer) error {
-	contents := PPMVersion +
+func writePPMHeaderWithVersion(canvas Canvas, dest io.Writer, version string, maxValue int) error {
+	contents := version +
 		"\n" +
 		strconv.Itoa(canvas.Width) +
 		" " +
 		strconv.Itoa(canvas.Height) +
 		"\n" +
-		strconv.Itoa(PPMMaxColorValue) +
+		strconv.Itoa(maxValue) +
 		"\n"
 
 	_, err := dest.Write([]byte(contents))
@@ -105,11 +204,18 @@ func writePPMHeader(canvas Canvas, dest io.Writer) error {
 // [0, PPM max color value]. Inputs outside the range [0, 1] are accepted but
 // will be clamped to the acceptable range.
 func scaleToPPMValue(value float64) int {
+	return scaleToMaxValue(value, PPMMaxColorValue)
+}
+
+// Scale a value from the range [0, 1] to a value in the range [0, maxValue].
+// Inputs outside the range [0, 1] are accepted but will be clamped to the
+// acceptable range.
+func scaleToMaxValue(value float64, maxValue int) int {
 	if value < 0 {
 		value = 0
 	} else if value > 1 {
 		value = 1
 	}
 
-	return int(math.Round(value * PPMMaxColorValue))
+	return int(math.Round(value * float64(maxValue)))
 }
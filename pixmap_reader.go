@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Read an image in either the ASCII (P3) or binary (P6) PPM format into a
+// canvas. Color values are scaled from the range [0, max color value] given in
+// the file's header to the range [0, 1].
+func ReadPPM(source io.Reader) (Canvas, error) {
+	reader := bufio.NewReader(source)
+
+	version, err := readPPMToken(reader)
+	if err != nil {
+		return Canvas{}, fmt.Errorf("failed to read PPM version: %w", err)
+	}
+
+	if version != PPMVersion && version != PPMBinaryVersion {
+		return Canvas{}, fmt.Errorf("unsupported PPM version '%s'", version)
+	}
+
+	header := make([]int, 3)
+	for i, name := range []string{"width", "height", "max color value"} {
+		value, err := readPPMInt(reader)
+		if err != nil {
+			return Canvas{}, fmt.Errorf("failed to read PPM %s: %w", name, err)
+		}
+
+		header[i] = value
+	}
+
+	width, height, maxValue := header[0], header[1], header[2]
+	if width < 1 || height < 1 {
+		return Canvas{}, fmt.Errorf("invalid PPM dimensions %dx%d", width, height)
+	}
+
+	if maxValue < 1 || maxValue > PPMLimitColorValue {
+		return Canvas{}, fmt.Errorf("invalid PPM max color value %d", maxValue)
+	}
+
+	canvas := MakeCanvas(width, height)
+	readValue := func() (int, error) { return readPPMInt(reader) }
+	if version == PPMBinaryVersion {
+		readValue = makePPMBinaryValueReader(reader, maxValue)
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var channels [3]float64
+			for i := range channels {
+				value, err := readValue()
+				if err != nil {
+					return Canvas{}, fmt.Errorf("failed to read pixel (%d, %d): %w", x, y, err)
+				}
+
+				if value > maxValue {
+					return Canvas{}, fmt.Errorf("pixel (%d, %d) has value %d exceeding the max color value %d", x, y, value, maxValue)
+				}
+
+				channels[i] = float64(value) / float64(maxValue)
+			}
+
+			canvas.SetPixel(x, y, MakeColor(channels[0], channels[1], channels[2]))
+		}
+	}
+
+	return canvas, nil
+}
+
+// Create a function that reads the next raw color value from the body of a
+// binary PPM. Values take two bytes, most significant first, when the maximum
+// color value is larger than 255.
+func makePPMBinaryValueReader(reader *bufio.Reader, maxValue int) func() (int, error) {
+	buffer := make([]byte, 1)
+	if maxValue > 255 {
+		buffer = make([]byte, 2)
+	}
+
+	return func() (int, error) {
+		if _, err := io.ReadFull(reader, buffer); err != nil {
+			return 0, err
+		}
+
+		value := 0
+		for _, b := range buffer {
+			value = value<<8 | int(b)
+		}
+
+		return value, nil
+	}
+}
+
+// Read the next token from a PPM and parse it as a non-negative integer.
+func readPPMInt(reader *bufio.Reader) (int, error) {
+	token, err := readPPMToken(reader)
+	if err != nil {
+		return 0, err
+	}
+
+	value, err := strconv.Atoi(token)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("expected a non-negative integer, got '%s'", token)
+	}
+
+	return value, nil
+}
+
+// Read the next whitespace delimited token from a PPM, skipping any comments.
+// The single whitespace character ending the token is consumed, which leaves
+// the reader at the start of the pixel data after reading a binary PPM's
+// header.
+func readPPMToken(reader *bufio.Reader) (string, error) {
+	var token []byte
+
+	for {
+		b, err := reader.ReadByte()
+		if errors.Is(err, io.EOF) && len(token) > 0 {
+			return string(token), nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case b == '#':
+			if len(token) > 0 {
+				// The comment ends the token, but it should still be
+				// skipped by the next read.
+				if err := reader.UnreadByte(); err != nil {
+					return "", err
+				}
+
+				return string(token), nil
+			}
+
+			// Comments run until the end of the line.
+			if _, err := reader.ReadBytes('\n'); err != nil && !errors.Is(err, io.EOF) {
+				return "", err
+			}
+		case isPPMWhitespace(b):
+			if len(token) > 0 {
+				return string(token), nil
+			}
+		default:
+			token = append(token, b)
+		}
+	}
+}
+
+// Determine if a byte is considered whitespace in a PPM header.
+func isPPMWhitespace(b byte) bool {
+	switch b {
+	case ' ', '\t', '\n', '\r', '\v', '\f':
+		return true
+	}
+
+	return false
+}
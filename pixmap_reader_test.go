@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestReadPPM(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+		want     [][]Color
+	}{
+		{
+			"ascii",
+			"P3\n2 1\n255\n255 0 0 0 51 255\n",
+			[][]Color{{MakeColor(1, 0, 0), MakeColor(0, 0.2, 1)}},
+		},
+		{
+			"ascii with comments and odd whitespace",
+			"P3 # a comment\n# a full line comment\n1\t2 #size\r\n10\n10 5 0\n\n  0 0 10",
+			[][]Color{{MakeColor(1, 0.5, 0)}, {MakeColor(0, 0, 1)}},
+		},
+		{
+			"ascii comment directly after a value",
+			"P3\n1 1\n4# comment\n4 2 0\n",
+			[][]Color{{MakeColor(1, 0.5, 0)}},
+		},
+		{
+			"binary",
+			"P6\n2 1\n255\n\xff\x00\x00\x00\x33\xff",
+			[][]Color{{MakeColor(1, 0, 0), MakeColor(0, 0.2, 1)}},
+		},
+		{
+			"binary with whitespace-looking pixel data",
+			"P6 1 1 255\n\x0a\x20\x23",
+			[][]Color{{MakeColor(10.0/255, 32.0/255, 35.0/255)}},
+		},
+		{
+			"binary 16-bit",
+			"P6\n1 1\n65535\n\xff\xff\x80\x00\x00\x00",
+			[][]Color{{MakeColor(1, 32768.0/65535, 0)}},
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			canvas, err := ReadPPM(strings.NewReader(tt.contents))
+			if err != nil {
+				t.Fatalf("ReadPPM() returned error: %v", err)
+			}
+
+			if canvas.Height != len(tt.want) || canvas.Width != len(tt.want[0]) {
+				t.Fatalf("Expected a %dx%d canvas, got %dx%d", len(tt.want[0]), len(tt.want), canvas.Width, canvas.Height)
+			}
+
+			for y, row := range tt.want {
+				for x, want := range row {
+					if got := canvas.GetPixel(x, y); !want.Equals(got) {
+						t.Errorf("Expected pixel (%d, %d) to be %v, got %v", x, y, want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReadPPM_Errors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+	}{
+		{"empty", ""},
+		{"unknown version", "P5\n1 1\n255\n\x00"},
+		{"bad width", "P3\nwide 1\n255\n0 0 0"},
+		{"zero height", "P3\n1 0\n255\n"},
+		{"max value too large", "P3\n1 1\n70000\n0 0 0"},
+		{"value exceeds max", "P3\n1 1\n10\n11 0 0"},
+		{"truncated ascii", "P3\n2 1\n255\n0 0 0 0"},
+		{"truncated binary", "P6\n2 1\n255\n\x00\x00\x00\x00"},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadPPM(strings.NewReader(tt.contents)); err == nil {
+				t.Error("Expected ReadPPM() to return an error")
+			}
+		})
+	}
+}
+
+// Writing a canvas and reading it back should give the same colors, within
+// the precision of the format.
+func TestReadPPM_RoundTrip(t *testing.T) {
+	source := MakeCanvas(3, 2)
+	source.SetPixel(0, 0, MakeColor(1, 0.5, 0.25))
+	source.SetPixel(1, 1, MakeColor(0.1, 0.2, 0.3))
+	source.SetPixel(2, 0, MakeColor(0.9, 0, 0.7))
+
+	for _, options := range []PPMOptions{
+		{Format: PPMASCII, MaxColorValue: 65535},
+		{Format: PPMBinary, MaxColorValue: 65535},
+	} {
+		var buffer bytes.Buffer
+		if err := WriteCanvasToPPMWithOptions(source, &buffer, options); err != nil {
+			t.Fatalf("WriteCanvasToPPMWithOptions() returned error: %v", err)
+		}
+
+		got, err := ReadPPM(&buffer)
+		if err != nil {
+			t.Fatalf("ReadPPM() returned error: %v", err)
+		}
+
+		for y := 0; y < source.Height; y++ {
+			for x := 0; x < source.Width; x++ {
+				if want, got := source.GetPixel(x, y), got.GetPixel(x, y); !want.Equals(got) {
+					t.Errorf("Expected pixel (%d, %d) to be %v, got %v", x, y, want, got)
+				}
+			}
+		}
+	}
+}
@@ -144,3 +144,125 @@ func TestScaleToPPMValue(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteCanvasToPPMWithOptions(t *testing.T) {
+	source := MakeCanvas(2, 1)
+	source.SetPixel(0, 0, MakeColor(1, 0.5, 0))
+	source.SetPixel(1, 0, MakeColor(0, 0.2, 1.5))
+
+	testCases := []struct {
+		name    string
+		options PPMOptions
+		want    string
+	}{
+		{
+			"ascii",
+			MakePPMOptions(),
+			"P3\n2 1\n255\n255 128 0 0 51 255\n",
+		},
+		{
+			"ascii 16-bit",
+			PPMOptions{Format: PPMASCII, MaxColorValue: 65535},
+			"P3\n2 1\n65535\n65535 32768 0 0 13107 65535\n",
+		},
+		{
+			"binary",
+			PPMOptions{Format: PPMBinary, MaxColorValue: 255},
+			"P6\n2 1\n255\n\xff\x80\x00\x00\x33\xff",
+		},
+		{
+			"binary 16-bit",
+			PPMOptions{Format: PPMBinary, MaxColorValue: 65535},
+			"P6\n2 1\n65535\n\xff\xff\x80\x00\x00\x00\x00\x00\x33\x33\xff\xff",
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			var writer strings.Builder
+
+			if err := WriteCanvasToPPMWithOptions(source, &writer, tt.options); err != nil {
+				t.Fatalf("WriteCanvasToPPMWithOptions() returned error: %v", err)
+			}
+
+			if got := writer.String(); got != tt.want {
+				t.Errorf("Expected contents to be %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteCanvasToPPMWithOptions_InvalidMaxValue(t *testing.T) {
+	for _, maxValue := range []int{0, 65536} {
+		var writer strings.Builder
+		options := PPMOptions{Format: PPMBinary, MaxColorValue: maxValue}
+
+		if err := WriteCanvasToPPMWithOptions(MakeCanvas(1, 1), &writer, options); err == nil {
+			t.Errorf("Expected error for max color value %d", maxValue)
+		}
+	}
+}
+
+func TestScaleToMaxValue(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    float64
+		maxValue int
+		want     int
+	}{
+		{"8-bit half", .5, 255, 128},
+		{"16-bit half", .5, 65535, 32768},
+		{"small max", .5, 3, 2},
+		{"clamped", 2, 1023, 1023},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scaleToMaxValue(tt.value, tt.maxValue); got != tt.want {
+				t.Errorf("Expected scaleToMaxValue(%f, %d) = %d, got %d", tt.value, tt.maxValue, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWriteCanvasToPPMWithOptions_Transform(t *testing.T) {
+	source := MakeCanvas(1, 1)
+	source.SetPixel(0, 0, MakeColor(0.5, 1, 3))
+
+	options := MakePPMOptions()
+	options.Transform.ToneMap = ToneMapReinhard
+	options.Transform.SRGB = true
+
+	// Reinhard maps the channels to 1/3, 1/2, and 3/4 before sRGB encoding.
+	want := "P3\n1 1\n255\n156 188 225\n"
+
+	var writer strings.Builder
+	if err := WriteCanvasToPPMWithOptions(source, &writer, options); err != nil {
+		t.Fatalf("WriteCanvasToPPMWithOptions() returned error: %v", err)
+	}
+
+	if got := writer.String(); got != want {
+		t.Errorf("Expected contents to be %#v, got %#v", want, got)
+	}
+}
+
+func TestWriteCanvasToPPMWithOptions_Dither(t *testing.T) {
+	source := MakeCanvas(2, 1)
+	source.SetPixel(0, 0, MakeColor(0.5, 0.5, 0.5))
+	source.SetPixel(1, 0, MakeColor(0.5, 0.5, 0.5))
+
+	options := MakePPMOptions()
+	options.MaxColorValue = 1
+	options.Dither = Dither{Method: DitherFloydSteinberg}
+
+	// Half of the error from rounding the first pixel up is pushed onto the
+	// second pixel, which is then rounded down.
+	want := "P3\n2 1\n1\n1 1 1 0 0 0\n"
+
+	var writer strings.Builder
+	if err := WriteCanvasToPPMWithOptions(source, &writer, options); err != nil {
+		t.Fatalf("WriteCanvasToPPMWithOptions() returned error: %v", err)
+	}
+
+	if got := writer.String(); got != want {
+		t.Errorf("Expected contents to be %#v, got %#v", want, got)
+	}
+}
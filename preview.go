@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+)
+
+// The page served by the preview server. The image is reloaded every time the
+// server reports progress.
+const previewPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Render Preview</title>
+<style>
+body { background: #222; color: #ddd; font-family: sans-serif; }
+img { image-rendering: pixelated; max-width: 100%; }
+</style>
+</head>
+<body>
+<p id="status">Waiting for the preview...</p>
+<img id="image" src="/image.png" alt="Render preview">
+<script>
+var events = new EventSource("/events");
+events.addEventListener("progress", function (event) {
+	var progress = JSON.parse(event.data);
+	document.getElementById("image").src = "/image.png?pass=" + progress.pass;
+	document.getElementById("status").textContent = progress.done
+		? "Finished " + progress.passes + " passes using " + progress.rays + " rays."
+		: progress.pass == 0
+		? "Rough preview, waiting for pass 1 of " + progress.passes + "..."
+		: "Pass " + progress.pass + " of " + progress.passes + "...";
+	if (progress.done) {
+		events.close();
+	}
+});
+</script>
+</body>
+</html>
+`
+
+// A preview server shows the current state of a progressive render over HTTP.
+// It serves a page that displays the image, the image itself as a PNG, and a
+// stream of server-sent events reporting the render's progress.
+type PreviewServer struct {
+	// The options used to encode the preview image.
+	png PNGOptions
+
+	mutex    sync.Mutex
+	progress RenderProgress
+	// The channels of the clients listening for progress events.
+	listeners map[chan RenderProgress]struct{}
+}
+
+// Create a preview server that encodes its image with the given PNG options.
+func MakePreviewServer(options PNGOptions) *PreviewServer {
+	return &PreviewServer{
+		png:       options,
+		listeners: make(map[chan RenderProgress]struct{}),
+	}
+}
+
+// Update the image shown by the server and notify listening clients. This is
+// meant to be used as the progress function of a progressive render.
+func (s *PreviewServer) Update(progress RenderProgress) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.progress = progress
+	for listener := range s.listeners {
+		// Listeners only care about the latest progress, so replace any
+		// update they haven't received yet.
+		select {
+		case <-listener:
+		default:
+		}
+		listener <- progress
+	}
+}
+
+// Get the handler that serves the preview.
+func (s *PreviewServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.servePage)
+	mux.HandleFunc("/image.png", s.serveImage)
+	mux.HandleFunc("/events", s.serveEvents)
+
+	return mux
+}
+
+func (s *PreviewServer) servePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, previewPage)
+}
+
+func (s *PreviewServer) serveImage(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	canvas := s.progress.Canvas
+	s.mutex.Unlock()
+
+	if canvas.Width == 0 || canvas.Height == 0 {
+		http.Error(w, "the preview has not been rendered yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Encode the image before writing anything so errors can still be
+	// reported with the right status.
+	var buffer bytes.Buffer
+	if err := WriteCanvasToPNGWithOptions(canvas, &buffer, s.png); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := buffer.WriteTo(w); err != nil {
+		log.Printf("Failed to send preview image: %v", err)
+	}
+}
+
+func (s *PreviewServer) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	listener := make(chan RenderProgress, 1)
+	s.mutex.Lock()
+	s.listeners[listener] = struct{}{}
+	// Start new clients off with the current progress, including the coarse
+	// preview shown before the first pass.
+	if s.progress.Canvas.Width > 0 {
+		listener <- s.progress
+	}
+	s.mutex.Unlock()
+
+	defer func() {
+		s.mutex.Lock()
+		delete(s.listeners, listener)
+		s.mutex.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case progress := <-listener:
+			if err := writeProgressEvent(w, progress); err != nil {
+				return
+			}
+			flusher.Flush()
+
+			if progress.Done() {
+				return
+			}
+		}
+	}
+}
+
+// Write the progress of a render as a server-sent event.
+func writeProgressEvent(w http.ResponseWriter, progress RenderProgress) error {
+	data, err := json.Marshal(struct {
+		Pass   int  `json:"pass"`
+		Passes int  `json:"passes"`
+		Rays   int  `json:"rays"`
+		Done   bool `json:"done"`
+	}{
+		Pass:   progress.Pass,
+		Passes: progress.Passes,
+		Rays:   progress.Stats.TotalRays(),
+		Done:   progress.Done(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: progress\ndata: %s\n\n", data)
+
+	return err
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreviewServer_Page(t *testing.T) {
+	server := httptest.NewServer(MakePreviewServer(MakePNGOptions()).Handler())
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("Failed to request page: %v", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, response.StatusCode)
+	}
+
+	if got := response.Header.Get("Content-Type"); !strings.HasPrefix(got, "text/html") {
+		t.Errorf("Expected an HTML page, got content type '%s'", got)
+	}
+}
+
+func TestPreviewServer_Image(t *testing.T) {
+	preview := MakePreviewServer(MakePNGOptions())
+	server := httptest.NewServer(preview.Handler())
+	defer server.Close()
+
+	// There is no image until the preview is rendered.
+	response, err := http.Get(server.URL + "/image.png")
+	if err != nil {
+		t.Fatalf("Failed to request image: %v", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before the preview, got %d", http.StatusServiceUnavailable, response.StatusCode)
+	}
+
+	canvas := MakeCanvas(3, 2)
+	canvas.SetPixel(1, 1, MakeColor(1, 0, 0))
+	preview.Update(RenderProgress{Pass: 1, Passes: 2, Canvas: canvas})
+
+	response, err = http.Get(server.URL + "/image.png")
+	if err != nil {
+		t.Fatalf("Failed to request image: %v", err)
+	}
+	defer response.Body.Close()
+
+	decoded, err := png.Decode(response.Body)
+	if err != nil {
+		t.Fatalf("Failed to decode image: %v", err)
+	}
+
+	if got := MakeCanvasFromImage(decoded).GetPixel(1, 1); !got.Equals(MakeColor(1, 0, 0)) {
+		t.Errorf("Expected pixel (1, 1) to be red, got %v", got)
+	}
+}
+
+func TestPreviewServer_Events(t *testing.T) {
+	preview := MakePreviewServer(MakePNGOptions())
+	server := httptest.NewServer(preview.Handler())
+	defer server.Close()
+
+	preview.Update(RenderProgress{Pass: 1, Passes: 2, Canvas: MakeCanvas(1, 1)})
+
+	response, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatalf("Failed to request events: %v", err)
+	}
+	defer response.Body.Close()
+
+	if got := response.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Expected an event stream, got content type '%s'", got)
+	}
+
+	scanner := bufio.NewScanner(response.Body)
+	nextEvent := func() string {
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+				return strings.TrimPrefix(line, "data: ")
+			}
+		}
+
+		return ""
+	}
+
+	// The stream starts with the current progress.
+	if want, got := `{"pass":1,"passes":2,"rays":0,"done":false}`, nextEvent(); want != got {
+		t.Errorf("Expected the first event to be %s, got %s", want, got)
+	}
+
+	preview.Update(RenderProgress{Pass: 2, Passes: 2, Canvas: MakeCanvas(1, 1)})
+
+	if want, got := `{"pass":2,"passes":2,"rays":0,"done":true}`, nextEvent(); want != got {
+		t.Errorf("Expected the second event to be %s, got %s", want, got)
+	}
+
+	// The stream ends after the final pass.
+	if got := nextEvent(); got != "" {
+		t.Errorf("Expected the stream to end, got %s", got)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"math/rand"
+)
+
+// The size in pixels of the blocks of the coarse preview shown before the
+// first pass of a progressive render.
+const progressivePreviewBlockSize = 8
+
+// A snapshot of a progressive render.
+type RenderProgress struct {
+	// The number of passes that have been completed. Each pass adds one
+	// sample to every pixel. Zero means the image is the coarse preview taken
+	// before the first pass.
+	Pass int
+	// The total number of passes the render will take.
+	Passes int
+	// The image as it looks after the completed passes.
+	Canvas Canvas
+	// Statistics about the work done so far.
+	Stats RenderStats
+}
+
+// Determine if the render has finished.
+func (p RenderProgress) Done() bool {
+	return p.Pass >= p.Passes
+}
+
+// Render a world progressively. Rather than finishing each pixel before moving
+// on to the next, every pass takes a single sample for each pixel and the
+// samples accumulate until every pixel has the requested number of samples.
+// The progress function is called with the current image after each pass, so
+// a preview of the whole image is available almost immediately and refines
+// over time. Since a single pass still traces every pixel, the progress
+// function is first called with a coarse preview that traces one ray for each
+// block of pixels, which shows the image long before the first pass finishes.
+//
+// Grid and jittered patterns visit the cells of the pattern's grid in a
+// shuffled order so that early passes are spread across the pixel. Adaptive
+// sampling and render passes are not supported by progressive renders.
+func RenderProgressive(camera Camera, world World, options RenderOptions, progress func(RenderProgress)) RenderResult {
+	var stats RenderStats
+	random := makeSampleRandom(options.Seed)
+	image := makeFilm(camera.Width, camera.Height, options.Filter)
+	region := options.sampledRegion(camera)
+	schedule := makeProgressiveSchedule(options.SamplePattern, options.SamplesPerPixel, random)
+
+	if progress != nil {
+		preview := renderCoarsePreview(camera, world, options, random, &stats)
+		progress(RenderProgress{
+			Pass:   0,
+			Passes: schedule.Passes(),
+			Canvas: RenderResult{Canvas: preview}.limitToRegion(camera, options).Canvas,
+			Stats:  stats,
+		})
+	}
+
+	var result RenderResult
+	for pass := 0; pass < schedule.Passes(); pass++ {
+		for y := region.Min.Y; y < region.Max.Y; y++ {
+			for x := region.Min.X; x < region.Max.X; x++ {
+				random.Seed(sampleSeed(options.Seed, float64(x), float64(y), pass))
+				offset := schedule.Offset(pass, random)
+				ray := camera.MakeRayForSample(x, y, makeCameraSample(camera, offset, random))
+				color, _, hit := traceCameraRay(world, ray, options, random)
+				stats.PrimaryRays++
+
+				color, alpha := applyBackground(color, hit, options)
+				image.AddSampleWithAlpha(float64(x)+offset.X, float64(y)+offset.Y, color, alpha)
+			}
+		}
+
+		result = RenderResult{Canvas: image.Canvas(), Stats: stats}.limitToRegion(camera, options)
+		if progress != nil {
+			progress(RenderProgress{
+				Pass:   pass + 1,
+				Passes: schedule.Passes(),
+				Canvas: result.Canvas,
+				Stats:  stats,
+			})
+		}
+	}
+
+	return result
+}
+
+// Render a blocky version of the image by tracing a single ray through the
+// middle of each block of pixels and filling the block with its color.
+func renderCoarsePreview(camera Camera, world World, options RenderOptions, random *rand.Rand, stats *RenderStats) Canvas {
+	canvas := MakeCanvas(camera.Width, camera.Height)
+	region := options.region(camera)
+	size := progressivePreviewBlockSize
+
+	for top := region.Min.Y; top < region.Max.Y; top += size {
+		bottom := top + size
+		if bottom > region.Max.Y {
+			bottom = region.Max.Y
+		}
+
+		for left := region.Min.X; left < region.Max.X; left += size {
+			right := left + size
+			if right > region.Max.X {
+				right = region.Max.X
+			}
+
+			// The preview uses its own random numbers so that the passes
+			// are unaffected by it.
+			x, y := (left+right-1)/2, (top+bottom-1)/2
+			random.Seed(sampleSeed(options.Seed, float64(x), float64(y), -1))
+			offset := SampleOffset{0.5, 0.5}
+			ray := camera.MakeRayForSample(x, y, makeCameraSample(camera, offset, random))
+			color, _, hit := traceCameraRay(world, ray, options, random)
+			stats.PrimaryRays++
+
+			color, alpha := applyBackground(color, hit, options)
+			for py := top; py < bottom; py++ {
+				for px := left; px < right; px++ {
+					canvas.SetPixel(px, py, color)
+					canvas.SetAlpha(px, py, alpha)
+				}
+			}
+		}
+	}
+
+	return canvas
+}
+
+// The order in which a progressive render takes the samples of each pixel.
+type progressiveSchedule struct {
+	pattern SamplePattern
+	columns int
+	rows    int
+
+	// The grid cell sampled in each pass.
+	cells []int
+}
+
+func makeProgressiveSchedule(pattern SamplePattern, count int, random *rand.Rand) progressiveSchedule {
+	if count < 1 {
+		count = 1
+	}
+
+	if pattern == SampleRandom {
+		return progressiveSchedule{pattern: pattern, columns: 1, rows: 1, cells: make([]int, count)}
+	}
+
+	columns, rows := sampleGridSize(count)
+
+	return progressiveSchedule{
+		pattern: pattern,
+		columns: columns,
+		rows:    rows,
+		cells:   random.Perm(columns * rows),
+	}
+}
+
+// Get the number of passes needed to take every sample.
+func (s progressiveSchedule) Passes() int {
+	return len(s.cells)
+}
+
+// Get the offset of a pixel's sample for a pass.
+func (s progressiveSchedule) Offset(pass int, random *rand.Rand) SampleOffset {
+	cellX, cellY := 0.5, 0.5
+	if s.pattern != SampleGrid {
+		cellX, cellY = random.Float64(), random.Float64()
+	}
+
+	cell := s.cells[pass]
+	column, row := cell%s.columns, cell/s.columns
+
+	return SampleOffset{
+		(float64(column) + cellX) / float64(s.columns),
+		(float64(row) + cellY) / float64(s.rows),
+	}
+}
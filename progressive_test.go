@@ -0,0 +1,89 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestRenderProgressive(t *testing.T) {
+	world := MakeDefaultWorld()
+	camera := MakeCamera(11, 11, math.Pi/2)
+	camera.Transform = ViewTransform(
+		MakePoint(0, 0, -5),
+		MakePoint(0, 0, 0),
+		MakeVector(0, 1, 0),
+	)
+
+	options := MakeRenderOptions()
+	options.SamplesPerPixel = 4
+
+	var updates []RenderProgress
+	result := RenderProgressive(camera, world, options, func(progress RenderProgress) {
+		updates = append(updates, progress)
+	})
+
+	if len(updates) != 5 {
+		t.Fatalf("Expected a preview and 4 progress updates, got %d updates", len(updates))
+	}
+
+	// The coarse preview traces one ray for each 8x8 block of pixels.
+	preview := updates[0]
+	if preview.Pass != 0 || preview.Passes != 4 || preview.Done() {
+		t.Errorf("Expected the first update to be the preview before pass 1 of 4, got %d of %d", preview.Pass, preview.Passes)
+	}
+
+	if want := 4; preview.Stats.PrimaryRays != want {
+		t.Errorf("Expected %d rays for the preview, got %d", want, preview.Stats.PrimaryRays)
+	}
+
+	if preview.Canvas.Width != camera.Width || preview.Canvas.Height != camera.Height {
+		t.Errorf("Expected a %dx%d preview, got %dx%d", camera.Width, camera.Height, preview.Canvas.Width, preview.Canvas.Height)
+	}
+
+	for i, update := range updates[1:] {
+		if update.Pass != i+1 || update.Passes != 4 {
+			t.Errorf("Expected update %d to be pass %d of 4, got %d of %d", i, i+1, update.Pass, update.Passes)
+		}
+
+		if want := preview.Stats.PrimaryRays + (i+1)*camera.Width*camera.Height; update.Stats.PrimaryRays != want {
+			t.Errorf("Expected %d rays after pass %d, got %d", want, i+1, update.Stats.PrimaryRays)
+		}
+
+		if update.Done() != (i == 3) {
+			t.Errorf("Expected update %d to be done = %v", i, i == 3)
+		}
+	}
+
+	// Once every pass is complete, the image matches a render taking all of
+	// the samples at once.
+	want := RenderWithOptions(camera, world, options).Canvas
+	for y := 0; y < camera.Height; y++ {
+		for x := 0; x < camera.Width; x++ {
+			if got := result.Canvas.GetPixel(x, y); !want.GetPixel(x, y).Equals(got) {
+				t.Errorf("Expected pixel (%d, %d) to be %v, got %v", x, y, want.GetPixel(x, y), got)
+			}
+		}
+	}
+}
+
+// Every cell of the sample grid should be visited exactly once.
+func TestProgressiveSchedule_Jittered(t *testing.T) {
+	random := rand.New(rand.NewSource(0))
+	schedule := makeProgressiveSchedule(SampleJittered, 9, random)
+
+	if schedule.Passes() != 9 {
+		t.Fatalf("Expected 9 passes, got %d", schedule.Passes())
+	}
+
+	visited := make(map[[2]int]bool)
+	for pass := 0; pass < schedule.Passes(); pass++ {
+		offset := schedule.Offset(pass, random)
+		cell := [2]int{int(offset.X * 3), int(offset.Y * 3)}
+		if visited[cell] {
+			t.Errorf("Expected cell %v to be visited once", cell)
+		}
+
+		visited[cell] = true
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// A projection maps points on a camera's canvas to rays in camera space. In
+// camera space, the camera looks down the negative z-axis with the positive
+// y-axis pointing up and the positive x-axis pointing to the left of the image.
+type Projection interface {
+	// Get the ray in camera space that passes through a point on the canvas.
+	// The point is given in pixel units measured from the top left corner of
+	// the canvas.
+	CameraRay(camera Camera, x, y float64) Ray
+}
+
+// A perspective projection models a pinhole camera. Rays start at the camera's
+// origin and pass through a canvas one unit in front of the camera. The
+// camera's field of view determines the size of the canvas.
+type PerspectiveProjection struct{}
+
+// Get the ray in camera space that passes through a point on the canvas.
+func (p PerspectiveProjection) CameraRay(camera Camera, x, y float64) Ray {
+	// The untransformed coordinates of the point, remembering that the canvas
+	// is at z = -1.
+	worldX := camera.halfWidth - x*camera.pixelSize
+	worldY := camera.halfHeight - y*camera.pixelSize
+
+	return MakeRay(MakePoint(0, 0, 0), MakeVector(worldX, worldY, -1))
+}
+
+// An orthographic projection shoots parallel rays from every point on the
+// canvas, so objects appear the same size regardless of their distance from
+// the camera. This is useful for technical drawings.
+type OrthographicProjection struct {
+	// The size of the view along its longer side in world-space units.
+	Size float64
+}
+
+// Get the ray in camera space that passes through a point on the canvas.
+func (p OrthographicProjection) CameraRay(camera Camera, x, y float64) Ray {
+	pixelSize := p.Size / math.Max(float64(camera.Width), float64(camera.Height))
+	halfWidth := float64(camera.Width) * pixelSize / 2
+	halfHeight := float64(camera.Height) * pixelSize / 2
+
+	origin := MakePoint(halfWidth-x*pixelSize, halfHeight-y*pixelSize, 0)
+
+	return MakeRay(origin, MakeVector(0, 0, -1))
+}
+
+// A fisheye projection maps the distance from the center of the canvas
+// linearly to the angle away from the camera's viewing direction. The camera's
+// field of view is the angle covered by the largest circle that fits within
+// the canvas. Points outside of that circle continue to bend further away
+// until they look directly behind the camera.
+type FisheyeProjection struct{}
+
+// Get the ray in camera space that passes through a point on the canvas.
+func (p FisheyeProjection) CameraRay(camera Camera, x, y float64) Ray {
+	radius := math.Min(float64(camera.Width), float64(camera.Height)) / 2
+	dx := float64(camera.Width)/2 - x
+	dy := float64(camera.Height)/2 - y
+
+	theta := math.Min(math.Pi, math.Hypot(dx, dy)/radius*camera.FieldOfView/2)
+	phi := math.Atan2(dy, dx)
+
+	direction := MakeVector(
+		math.Sin(theta)*math.Cos(phi),
+		math.Sin(theta)*math.Sin(phi),
+		-math.Cos(theta),
+	)
+
+	return MakeRay(MakePoint(0, 0, 0), direction)
+}
+
+// An equirectangular projection captures every direction around the camera.
+// The horizontal axis of the canvas spans 360 degrees of longitude and the
+// vertical axis spans 180 degrees of latitude, which is the format used for
+// panoramas in VR viewers. The camera's field of view is ignored.
+type EquirectangularProjection struct{}
+
+// Get the ray in camera space that passes through a point on the canvas.
+func (p EquirectangularProjection) CameraRay(camera Camera, x, y float64) Ray {
+	// The center of the canvas looks straight ahead.
+	direction := equirectangularDirection(x/float64(camera.Width), y/float64(camera.Height))
+
+	return MakeRay(MakePoint(0, 0, 0), direction)
+}
+
+// Get a projection by its name as it would be given on the command line.
+// Orthographic projections are given a size of 10 world-space units.
+func ParseProjection(name string) (Projection, error) {
+	switch name {
+	case "perspective":
+		return PerspectiveProjection{}, nil
+	case "orthographic":
+		return OrthographicProjection{Size: 10}, nil
+	case "fisheye":
+		return FisheyeProjection{}, nil
+	case "equirectangular":
+		return EquirectangularProjection{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown projection '%s'", name)
+}
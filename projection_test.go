@@ -0,0 +1,175 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProjection_CameraRay(t *testing.T) {
+	testCases := []struct {
+		name          string
+		projection    Projection
+		camera        Camera
+		x             float64
+		y             float64
+		wantOrigin    Tuple
+		wantDirection Tuple
+	}{
+		{
+			"perspective center",
+			PerspectiveProjection{},
+			MakeCamera(200, 100, math.Pi/2),
+			100,
+			50,
+			MakePoint(0, 0, 0),
+			MakeVector(0, 0, -1),
+		},
+		{
+			"perspective left edge",
+			PerspectiveProjection{},
+			MakeCamera(200, 100, math.Pi/2),
+			0,
+			50,
+			MakePoint(0, 0, 0),
+			MakeVector(1, 0, -1).Normalized(),
+		},
+		{
+			"orthographic center",
+			OrthographicProjection{Size: 4},
+			MakeCamera(200, 100, math.Pi/2),
+			100,
+			50,
+			MakePoint(0, 0, 0),
+			MakeVector(0, 0, -1),
+		},
+		{
+			"orthographic top left",
+			OrthographicProjection{Size: 4},
+			MakeCamera(200, 100, math.Pi/2),
+			0,
+			0,
+			MakePoint(2, 1, 0),
+			MakeVector(0, 0, -1),
+		},
+		{
+			"fisheye center",
+			FisheyeProjection{},
+			MakeCamera(100, 100, math.Pi),
+			50,
+			50,
+			MakePoint(0, 0, 0),
+			MakeVector(0, 0, -1),
+		},
+		{
+			"fisheye edge of image circle",
+			FisheyeProjection{},
+			MakeCamera(100, 100, math.Pi),
+			50,
+			0,
+			MakePoint(0, 0, 0),
+			MakeVector(0, 1, 0),
+		},
+		{
+			"fisheye beyond image circle looks behind",
+			FisheyeProjection{},
+			MakeCamera(200, 100, math.Pi),
+			200,
+			50,
+			MakePoint(0, 0, 0),
+			MakeVector(0, 0, 1),
+		},
+		{
+			"equirectangular center",
+			EquirectangularProjection{},
+			MakeCamera(400, 200, math.Pi/2),
+			200,
+			100,
+			MakePoint(0, 0, 0),
+			MakeVector(0, 0, -1),
+		},
+		{
+			"equirectangular quarter turn left",
+			EquirectangularProjection{},
+			MakeCamera(400, 200, math.Pi/2),
+			100,
+			100,
+			MakePoint(0, 0, 0),
+			MakeVector(1, 0, 0),
+		},
+		{
+			"equirectangular behind",
+			EquirectangularProjection{},
+			MakeCamera(400, 200, math.Pi/2),
+			0,
+			100,
+			MakePoint(0, 0, 0),
+			MakeVector(0, 0, 1),
+		},
+		{
+			"equirectangular straight up",
+			EquirectangularProjection{},
+			MakeCamera(400, 200, math.Pi/2),
+			123,
+			0,
+			MakePoint(0, 0, 0),
+			MakeVector(0, 1, 0),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.projection.CameraRay(tt.camera, tt.x, tt.y)
+
+			if !tt.wantOrigin.Equals(got.Origin) {
+				t.Errorf("Expected ray origin %v, got %v", tt.wantOrigin, got.Origin)
+			}
+
+			if direction := got.Direction.Normalized(); !tt.wantDirection.Equals(direction) {
+				t.Errorf("Expected ray direction %v, got %v", tt.wantDirection, direction)
+			}
+		})
+	}
+}
+
+// Changing the projection should change the rays produced by the camera
+// without callers needing to do anything differently.
+func TestCamera_MakeRayForPixel_Projection(t *testing.T) {
+	camera := MakeCamera(11, 11, math.Pi/2)
+	camera.Transform = MakeTranslation(0, 0, -5)
+	camera.Projection = OrthographicProjection{Size: 11}
+
+	got := camera.MakeRayForPixel(0, 5)
+
+	if want := MakePoint(5, 0, 5); !want.Equals(got.Origin) {
+		t.Errorf("Expected ray origin %v, got %v", want, got.Origin)
+	}
+
+	if want := MakeVector(0, 0, -1); !want.Equals(got.Direction) {
+		t.Errorf("Expected ray direction %v, got %v", want, got.Direction)
+	}
+}
+
+func TestCamera_MakeRayForPixel_NoProjection(t *testing.T) {
+	camera := MakeCamera(201, 101, math.Pi/2)
+	want := camera.MakeRayForPixel(20, 30)
+
+	camera.Projection = nil
+	got := camera.MakeRayForPixel(20, 30)
+
+	if !want.Direction.Equals(got.Direction) {
+		t.Errorf("Expected ray direction %v, got %v", want.Direction, got.Direction)
+	}
+}
+
+func TestParseProjection(t *testing.T) {
+	for _, name := range []string{"perspective", "orthographic", "fisheye", "equirectangular"} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseProjection(name); err != nil {
+				t.Errorf("Expected projection '%s' to parse, got error %v", name, err)
+			}
+		})
+	}
+
+	if _, err := ParseProjection("bogus"); err == nil {
+		t.Error("Expected error parsing unknown projection")
+	}
+}
@@ -3,10 +3,19 @@ package main
 type Ray struct {
 	Origin    Tuple
 	Direction Tuple
+
+	// The moment in the scene's animation that the ray was cast. Moving objects
+	// are positioned according to the time of the rays that intersect them.
+	Time float64
 }
 
 func MakeRay(origin, direction Tuple) Ray {
-	return Ray{origin, direction}
+	return Ray{Origin: origin, Direction: direction}
+}
+
+// Create a ray that is cast at a specific moment in the scene's animation.
+func MakeRayAtTime(origin, direction Tuple, time float64) Ray {
+	return Ray{Origin: origin, Direction: direction, Time: time}
 }
 
 // Get the position of a ray at the given time.
@@ -14,11 +23,13 @@ func (r Ray) Position(t float64) Tuple {
 	return r.Origin.Add(r.Direction.Multiply(t))
 }
 
-// Create a new ray by applying a transformation to the current ray.
+// Create a new ray by applying a transformation to the current ray. The new ray
+// is cast at the same time as the current ray.
 func (r Ray) Transform(transform Matrix) Ray {
-	return MakeRay(
+	return MakeRayAtTime(
 		transform.TupleMultiply(r.Origin),
 		transform.TupleMultiply(r.Direction),
+		r.Time,
 	)
 }
 
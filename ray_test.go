@@ -108,3 +108,15 @@ func TestReflect(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeRayAtTime(t *testing.T) {
+	ray := MakeRayAtTime(MakePoint(1, 2, 3), MakeVector(0, 0, 1), 0.75)
+
+	if got := ray.Time; !Float64Equal(0.75, got) {
+		t.Errorf("Expected ray.Time = 0.75, got %v", got)
+	}
+
+	if got := ray.Transform(MakeTranslation(1, 0, 0)).Time; !Float64Equal(0.75, got) {
+		t.Errorf("Expected transformed ray to keep its time, got %v", got)
+	}
+}
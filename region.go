@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// Get the region of the camera's canvas that should be rendered. An empty
+// region in the options renders the whole canvas, and regions extending past
+// the edges of the canvas are clipped to it.
+func (o RenderOptions) region(camera Camera) image.Rectangle {
+	frame := image.Rect(0, 0, camera.Width, camera.Height)
+	if o.Region.Empty() {
+		return frame
+	}
+
+	return o.Region.Intersect(frame)
+}
+
+// Check that the region given in the options overlaps the camera's canvas. A
+// region entirely outside of the canvas would render an empty image.
+func (o RenderOptions) checkRegion(camera Camera) error {
+	frame := image.Rect(0, 0, camera.Width, camera.Height)
+	if !o.Region.Empty() && o.Region.Intersect(frame).Empty() {
+		return fmt.Errorf("region %v lies outside of the %dx%d canvas", o.Region, camera.Width, camera.Height)
+	}
+
+	return nil
+}
+
+// Get the pixels whose samples reach a region through the render's filter.
+// Tracing these pixels gives the pixels of the region the same values they
+// would have in a render of the whole canvas.
+func (o RenderOptions) sampledRegion(camera Camera) image.Rectangle {
+	region := o.region(camera)
+	margin := int(math.Max(0, math.Ceil(o.Filter.Radius()-0.5)))
+
+	return region.Inset(-margin).Intersect(image.Rect(0, 0, camera.Width, camera.Height))
+}
+
+// Limit a rendered canvas to a region. If the canvas is cropped, a canvas the
+// size of the region is returned. Otherwise the canvas keeps its size and the
+// pixels outside the region are cleared to transparent black.
+func applyRegion(canvas Canvas, region image.Rectangle, crop bool) Canvas {
+	if crop {
+		cropped := MakeCanvas(region.Dx(), region.Dy())
+		for y := 0; y < cropped.Height; y++ {
+			for x := 0; x < cropped.Width; x++ {
+				cropped.SetPixel(x, y, canvas.GetPixel(region.Min.X+x, region.Min.Y+y))
+				cropped.SetAlpha(x, y, canvas.GetAlpha(region.Min.X+x, region.Min.Y+y))
+			}
+		}
+
+		return cropped
+	}
+
+	for y := 0; y < canvas.Height; y++ {
+		for x := 0; x < canvas.Width; x++ {
+			if !(image.Point{x, y}.In(region)) {
+				canvas.SetPixel(x, y, MakeColor(0, 0, 0))
+				canvas.SetAlpha(x, y, 0)
+			}
+		}
+	}
+
+	return canvas
+}
+
+// Limit a render's canvas and passes to the region given in the options.
+func (r RenderResult) limitToRegion(camera Camera, options RenderOptions) RenderResult {
+	if options.Region.Empty() {
+		return r
+	}
+
+	region := options.region(camera)
+	r.Canvas = applyRegion(r.Canvas, region, options.CropToRegion)
+	for pass, canvas := range r.Passes {
+		r.Passes[pass] = applyRegion(canvas, region, options.CropToRegion)
+	}
+
+	return r
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestRenderWithOptions_Region(t *testing.T) {
+	world := MakeDefaultWorld()
+	camera := MakeCamera(11, 11, math.Pi/2)
+	camera.Transform = ViewTransform(
+		MakePoint(0, 0, -5),
+		MakePoint(0, 0, 0),
+		MakeVector(0, 1, 0),
+	)
+	region := image.Rect(2, 3, 7, 5)
+
+	testCases := []struct {
+		name     string
+		adaptive bool
+		crop     bool
+		pattern  SamplePattern
+		aperture float64
+	}{
+		{"supersampled", false, false, SampleGrid, 0},
+		{"supersampled cropped", false, true, SampleGrid, 0},
+		{"adaptive", true, false, SampleGrid, 0},
+		{"adaptive cropped", true, true, SampleGrid, 0},
+		// Randomized samples are drawn from each pixel's own stream of random
+		// numbers, so they match too.
+		{"jittered with aperture", false, false, SampleJittered, 0.2},
+		{"jittered with aperture cropped", false, true, SampleJittered, 0.2},
+		{"adaptive with aperture", true, false, SampleGrid, 0.2},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			camera := camera
+			camera.Aperture = tt.aperture
+			camera.FocalDistance = 5
+
+			options := MakeRenderOptions()
+			options.SamplesPerPixel = 4
+			options.SamplePattern = tt.pattern
+			options.Filter = MakeMitchellFilter()
+			options.Adaptive = tt.adaptive
+			options.Seed = 7
+			full := RenderWithOptions(camera, world, options).Canvas
+
+			options.Region = region
+			options.CropToRegion = tt.crop
+			partial := RenderWithOptions(camera, world, options).Canvas
+
+			offset := image.Point{}
+			wantWidth, wantHeight := camera.Width, camera.Height
+			if tt.crop {
+				offset = region.Min
+				wantWidth, wantHeight = region.Dx(), region.Dy()
+			}
+
+			if partial.Width != wantWidth || partial.Height != wantHeight {
+				t.Fatalf("Expected a %dx%d canvas, got %dx%d", wantWidth, wantHeight, partial.Width, partial.Height)
+			}
+
+			for y := 0; y < camera.Height; y++ {
+				for x := 0; x < camera.Width; x++ {
+					inside := image.Point{x, y}.In(region)
+					if !inside && tt.crop {
+						continue
+					}
+
+					want, wantAlpha := MakeColor(0, 0, 0), 0.0
+					if inside {
+						want, wantAlpha = full.GetPixel(x, y), full.GetAlpha(x, y)
+					}
+
+					got := partial.GetPixel(x-offset.X, y-offset.Y)
+					gotAlpha := partial.GetAlpha(x-offset.X, y-offset.Y)
+					if !want.Equals(got) || wantAlpha != gotAlpha {
+						t.Errorf(
+							"Expected pixel (%d, %d) to be %v with alpha %v, got %v with alpha %v",
+							x, y, want, wantAlpha, got, gotAlpha,
+						)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRenderWithOptions_RegionClipped(t *testing.T) {
+	camera := MakeCamera(4, 4, math.Pi/2)
+
+	options := MakeRenderOptions()
+	options.Region = image.Rect(2, -1, 10, 3)
+	options.CropToRegion = true
+	result := RenderWithOptions(camera, MakeDefaultWorld(), options)
+
+	if result.Canvas.Width != 2 || result.Canvas.Height != 3 {
+		t.Errorf("Expected the region to be clipped to 2x3, got %dx%d", result.Canvas.Width, result.Canvas.Height)
+	}
+
+	if want := 6; result.Stats.PrimaryRays != want {
+		t.Errorf("Expected %d primary rays, got %d", want, result.Stats.PrimaryRays)
+	}
+}
+
+func TestRenderOptions_CheckRegion(t *testing.T) {
+	camera := MakeCamera(4, 4, math.Pi/2)
+
+	testCases := []struct {
+		name    string
+		region  image.Rectangle
+		wantErr bool
+	}{
+		{"whole canvas", image.Rectangle{}, false},
+		{"inside", image.Rect(1, 1, 3, 3), false},
+		{"partly outside", image.Rect(2, -1, 10, 3), false},
+		{"right of canvas", image.Rect(6, 0, 16, 10), true},
+		{"above canvas", image.Rect(0, -5, 4, 0), true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			options := MakeRenderOptions()
+			options.Region = tt.region
+
+			err := options.checkRegion(camera)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
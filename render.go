@@ -1,21 +1,182 @@
 package main
 
-import "log"
+import (
+	"image"
+	"log"
+	"math/rand"
+)
+
+// Options controlling how a world is rendered.
+type RenderOptions struct {
+	// The number of samples taken for each pixel.
+	SamplesPerPixel int
+	// The way samples are distributed within each pixel.
+	SamplePattern SamplePattern
+	// The filter used to combine samples into pixel colors.
+	Filter Filter
+
+	// Adaptive anti-aliasing only subdivides pixels with high contrast rather
+	// than supersampling every pixel. When enabled, the sample count, pattern,
+	// and filter are ignored.
+	Adaptive bool
+	// The largest difference between the samples in a pixel, for any color
+	// channel, that is tolerated before the pixel is subdivided.
+	AdaptiveThreshold float64
+	// The maximum number of times a pixel may be subdivided.
+	AdaptiveMaxDepth int
+
+	// The seed for the random number generator used by randomized sampling.
+	// Renders using the same seed produce identical images. Each pixel draws
+	// its random numbers from its own stream derived from the seed.
+	Seed int64
+
+	// The strategy used to compute the color seen along each ray from the
+	// camera.
+	Integrator Integrator
+
+	// The additional render passes to produce alongside the image.
+	Passes RenderPass
+
+	// Render the world's environment as transparent so the image can be
+	// composited over other imagery. The alpha value of each pixel is then the
+	// fraction of the pixel covered by objects.
+	TransparentBackground bool
+
+	// The rectangle of pixels to render, which allows iterating on a detail
+	// of an image without rendering the whole thing. Each pixel's random
+	// numbers are derived from its position, so rays are traced exactly as
+	// they would be for the whole canvas with any sample pattern, lens, or
+	// shutter. An empty region renders every pixel.
+	Region image.Rectangle
+	// Return a canvas the size of the region rather than a canvas the size of
+	// the camera's view with the pixels outside the region left transparent.
+	CropToRegion bool
+}
+
+// Create render options that trace a single ray through the center of each
+// pixel.
+func MakeRenderOptions() RenderOptions {
+	return RenderOptions{
+		SamplesPerPixel: 1,
+		SamplePattern:   SampleGrid,
+		Filter:          MakeBoxFilter(),
+
+		AdaptiveThreshold: 0.1,
+		AdaptiveMaxDepth:  3,
+
+		Integrator: WhittedIntegrator{},
+	}
+}
+
+// The result of rendering a world.
+type RenderResult struct {
+	// The rendered image.
+	Canvas Canvas
+	// Statistics about the work done to render the image.
+	Stats RenderStats
+	// The canvas of each render pass requested by the render's options.
+	Passes map[RenderPass]Canvas
+}
+
+// Statistics collected while rendering.
+type RenderStats struct {
+	// The number of rays traced from the camera before any adaptive
+	// refinement.
+	PrimaryRays int
+	// The number of additional rays traced to refine high contrast pixels.
+	AdaptiveRays int
+	// The number of pixels that were subdivided at least once.
+	SubdividedPixels int
+}
+
+// Get the total number of rays traced from the camera.
+func (s RenderStats) TotalRays() int {
+	return s.PrimaryRays + s.AdaptiveRays
+}
 
 // Render a world using the view of a specific camera.
 func Render(camera Camera, world World) Canvas {
-	image := MakeCanvas(camera.Width, camera.Height)
+	return RenderWithOptions(camera, world, MakeRenderOptions()).Canvas
+}
 
-	for y := 0; y < camera.Height; y++ {
-		for x := 0; x < camera.Width; x++ {
-			ray := camera.MakeRayForPixel(x, y)
-			color := world.ColorAt(ray)
+// Render a world using the view of a specific camera and the given options.
+func RenderWithOptions(camera Camera, world World, options RenderOptions) RenderResult {
+	var result RenderResult
+	if options.Adaptive {
+		result = renderAdaptive(camera, world, options)
+	} else {
+		result = renderSupersampled(camera, world, options)
+	}
+
+	return result.limitToRegion(camera, options)
+}
+
+// Render a world by taking a fixed number of samples for every pixel.
+func renderSupersampled(camera Camera, world World, options RenderOptions) RenderResult {
+	var stats RenderStats
+	random := makeSampleRandom(options.Seed)
+	image := makeFilm(camera.Width, camera.Height, options.Filter)
+	passes := makePassBuffers(camera.Width, camera.Height, options.Passes, options.Filter)
+	region := options.sampledRegion(camera)
+
+	for y := region.Min.Y; y < region.Max.Y; y++ {
+		for x := region.Min.X; x < region.Max.X; x++ {
+			random.Seed(sampleSeed(options.Seed, float64(x), float64(y), 0))
+			for _, offset := range options.SamplePattern.Offsets(options.SamplesPerPixel, random) {
+				ray := camera.MakeRayForSample(x, y, makeCameraSample(camera, offset, random))
+				color, computation, hit := traceCameraRay(world, ray, options, random)
+				stats.PrimaryRays++
 
-			image.SetPixel(x, y, color)
+				color, alpha := applyBackground(color, hit, options)
+				sampleX, sampleY := float64(x)+offset.X, float64(y)+offset.Y
+				image.AddSampleWithAlpha(sampleX, sampleY, color, alpha)
+				passes.AddSample(sampleX, sampleY, computation, hit)
+			}
 		}
 
-		log.Printf("Rendered row %d of %d\n", y+1, camera.Height)
+		log.Printf("Rendered row %d of %d\n", y+1-region.Min.Y, region.Dy())
+	}
+
+	return RenderResult{Canvas: image.Canvas(), Stats: stats, Passes: passes.Canvases()}
+}
+
+// Compute the color seen along a camera ray using the options' integrator,
+// along with the computations of the ray's first hit. The boolean return value
+// is false if the ray missed every object. The first hit is found once and
+// shared with integrators that can shade a known hit.
+func traceCameraRay(world World, ray Ray, options RenderOptions, sampler Sampler) (Color, IntersectionComputation, bool) {
+	computation, hit := world.primaryHit(ray)
+	if integrator, ok := options.Integrator.(hitIntegrator); ok {
+		return integrator.radianceForHit(ray, world, computation, hit, sampler), computation, hit
+	}
+
+	return options.Integrator.Radiance(ray, world, 0, sampler), computation, hit
+}
+
+// Get the premultiplied color and alpha value of a camera sample. Samples that
+// missed every object are transparent if the background is transparent.
+func applyBackground(color Color, hit bool, options RenderOptions) (Color, float64) {
+	if !hit && options.TransparentBackground {
+		return MakeColor(0, 0, 0), 0
+	}
+
+	return color, 1
+}
+
+// Create a camera sample at the given offset within a pixel. The lens is only
+// sampled if the camera has an aperture and the time is only sampled if the
+// camera's shutter stays open, so that simple renders consume the same random
+// numbers regardless of the camera's settings.
+func makeCameraSample(camera Camera, offset SampleOffset, random *rand.Rand) CameraSample {
+	sample := CameraSample{PixelX: offset.X, PixelY: offset.Y, LensU: 0.5, LensV: 0.5}
+	if camera.Aperture > 0 {
+		sample.LensU = random.Float64()
+		sample.LensV = random.Float64()
+	}
+
+	if camera.ShutterClose != camera.ShutterOpen {
+		sample.Time = random.Float64()
 	}
 
-	return image
+	return sample
 }
@@ -21,3 +21,161 @@ func TestRender(t *testing.T) {
 		t.Errorf("Expected pixel at (5, 5) to be %v, got %v", want, got)
 	}
 }
+
+func TestRenderWithOptions_Supersampling(t *testing.T) {
+	world := MakeDefaultWorld()
+	camera := MakeCamera(11, 11, math.Pi/2)
+	camera.Transform = ViewTransform(
+		MakePoint(0, 0, -5),
+		MakePoint(0, 0, 0),
+		MakeVector(0, 1, 0),
+	)
+
+	single := Render(camera, world)
+
+	options := MakeRenderOptions()
+	options.SamplesPerPixel = 16
+	options.SamplePattern = SampleJittered
+	options.Filter = MakeTentFilter()
+	options.Seed = 1
+	supersampled := RenderWithOptions(camera, world, options).Canvas
+
+	// The center of the sphere is uniformly shaded, so supersampling should
+	// barely change it.
+	want := single.GetPixel(5, 5)
+	got := supersampled.GetPixel(5, 5)
+	if diff := want.Subtract(got); diff.Red() > 0.05 || diff.Red() < -0.05 {
+		t.Errorf("Expected pixel at (5, 5) to be close to %v, got %v", want, got)
+	}
+
+	// The same seed should always produce the same image.
+	again := RenderWithOptions(camera, world, options).Canvas
+	for y := 0; y < camera.Height; y++ {
+		for x := 0; x < camera.Width; x++ {
+			if a, b := supersampled.GetPixel(x, y), again.GetPixel(x, y); !a.Equals(b) {
+				t.Errorf("Expected pixel (%d, %d) to match between renders; got %v and %v", x, y, a, b)
+			}
+		}
+	}
+}
+
+// Pixels on the silhouette of the sphere should blend the sphere and the
+// background when supersampled.
+func TestRenderWithOptions_AntiAliasedEdge(t *testing.T) {
+	world := MakeDefaultWorld()
+	camera := MakeCamera(11, 11, math.Pi/2)
+	camera.Transform = ViewTransform(
+		MakePoint(0, 0, -5),
+		MakePoint(0, 0, 0),
+		MakeVector(0, 1, 0),
+	)
+
+	options := MakeRenderOptions()
+	options.SamplesPerPixel = 64
+	image := RenderWithOptions(camera, world, options).Canvas
+
+	foundBlend := false
+	for x := 0; x < camera.Width; x++ {
+		green := image.GetPixel(x, 5).Green()
+		if green > 0.01 && green < 0.3 {
+			foundBlend = true
+		}
+	}
+
+	if !foundBlend {
+		t.Error("Expected a partially covered pixel along the middle row")
+	}
+}
+
+func TestRenderWithOptions_Stats(t *testing.T) {
+	camera := MakeCamera(4, 3, math.Pi/2)
+	options := MakeRenderOptions()
+	options.SamplesPerPixel = 4
+
+	stats := RenderWithOptions(camera, MakeDefaultWorld(), options).Stats
+
+	if want, got := 48, stats.PrimaryRays; got != want {
+		t.Errorf("Expected %d primary rays, got %d", want, got)
+	}
+
+	if got := stats.AdaptiveRays; got != 0 {
+		t.Errorf("Expected no adaptive rays, got %d", got)
+	}
+}
+
+// A sphere moving across the view while the shutter is open should leave a
+// streak that is dimmer than the sphere itself.
+func TestRenderWithOptions_MotionBlur(t *testing.T) {
+	material := MakeMaterial()
+	material.Ambient = 1
+	material.Diffuse = 0
+	material.Specular = 0
+
+	start := MakeKeyframe()
+	start.Translation = MakeVector(-2, 0, 0)
+	end := MakeKeyframe()
+	end.Translation = MakeVector(2, 0, 0)
+	sphere := MakeSphereAnimated(MakeAnimatedTransform(start, end))
+	sphere.material = material
+
+	world := MakeWorld()
+	world.Light = MakePointLight(MakePoint(0, 0, -10), MakeColor(1, 1, 1))
+	world.Objects = []Object{sphere}
+
+	camera := MakeCamera(11, 11, math.Pi/2)
+	camera.Transform = ViewTransform(MakePoint(0, 0, -5), MakePoint(0, 0, 0), MakeVector(0, 1, 0))
+	camera.ShutterClose = 1
+
+	options := MakeRenderOptions()
+	options.SamplesPerPixel = 64
+	options.SamplePattern = SampleJittered
+	image := RenderWithOptions(camera, world, options).Canvas
+
+	// The center of the image is only covered for part of the interval.
+	if got := image.GetPixel(5, 5).Red(); got <= 0.1 || got >= 0.9 {
+		t.Errorf("Expected a partially covered pixel at (5, 5), got %v", got)
+	}
+}
+
+func TestRenderWithOptions_TransparentBackground(t *testing.T) {
+	world := MakeDefaultWorld()
+	world.Environment = MakeConstantEnvironment(MakeColor(0, 0, 1))
+	camera := MakeCamera(11, 11, math.Pi/2)
+	camera.Transform = ViewTransform(
+		MakePoint(0, 0, -5),
+		MakePoint(0, 0, 0),
+		MakeVector(0, 1, 0),
+	)
+
+	testCases := []struct {
+		name        string
+		transparent bool
+		adaptive    bool
+		wantCorner  Color
+		wantAlpha   float64
+	}{
+		{"opaque", false, false, MakeColor(0, 0, 1), 1},
+		{"transparent", true, false, MakeColor(0, 0, 0), 0},
+		{"transparent adaptive", true, true, MakeColor(0, 0, 0), 0},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			options := MakeRenderOptions()
+			options.TransparentBackground = tt.transparent
+			options.Adaptive = tt.adaptive
+			image := RenderWithOptions(camera, world, options).Canvas
+
+			if got := image.GetPixel(0, 0); !tt.wantCorner.Equals(got) {
+				t.Errorf("Expected corner to be %v, got %v", tt.wantCorner, got)
+			}
+
+			if got := image.GetAlpha(0, 0); got != tt.wantAlpha {
+				t.Errorf("Expected corner to have alpha %v, got %v", tt.wantAlpha, got)
+			}
+
+			if got := image.GetAlpha(5, 5); got != 1 {
+				t.Errorf("Expected center to be opaque, got alpha %v", got)
+			}
+		})
+	}
+}
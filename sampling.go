@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// A sample pattern determines how the samples for a single pixel are
+// distributed across the area of that pixel.
+type SamplePattern int
+
+const (
+	// Samples are placed at the centers of the cells of a regular grid.
+	SampleGrid SamplePattern = iota
+	// Samples are placed at a random location within each cell of a regular
+	// grid. This is also known as stratified sampling.
+	SampleJittered
+	// Samples are placed at random locations anywhere within the pixel.
+	SampleRandom
+)
+
+// The location of a sample within a pixel. Offsets are given in pixel units
+// measured from the top left corner of the pixel, so both components lie in the
+// range [0, 1).
+type SampleOffset struct {
+	X float64
+	Y float64
+}
+
+// Parse the name of a sample pattern as it would be given on the command line.
+func ParseSamplePattern(name string) (SamplePattern, error) {
+	switch name {
+	case "grid":
+		return SampleGrid, nil
+	case "jittered", "stratified":
+		return SampleJittered, nil
+	case "random":
+		return SampleRandom, nil
+	}
+
+	return SampleGrid, fmt.Errorf("unknown sample pattern '%s'", name)
+}
+
+// Get the number of columns and rows of the grid used to place a number of
+// samples. The grid is as close to square as possible.
+func sampleGridSize(count int) (columns, rows int) {
+	columns = int(math.Ceil(math.Sqrt(float64(count))))
+	rows = (count + columns - 1) / columns
+
+	return columns, rows
+}
+
+// Generate the sample offsets for a single pixel. The grid and jittered
+// patterns place samples in the cells of a grid that is as close to square as
+// possible, so they may produce slightly more samples than requested. A count
+// less than one is treated as a single sample.
+func (p SamplePattern) Offsets(count int, random *rand.Rand) []SampleOffset {
+	if count < 1 {
+		count = 1
+	}
+
+	if p == SampleRandom {
+		offsets := make([]SampleOffset, count)
+		for i := range offsets {
+			offsets[i] = SampleOffset{random.Float64(), random.Float64()}
+		}
+
+		return offsets
+	}
+
+	columns, rows := sampleGridSize(count)
+	cellWidth := 1 / float64(columns)
+	cellHeight := 1 / float64(rows)
+
+	offsets := make([]SampleOffset, 0, columns*rows)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < columns; col++ {
+			// Grid samples sit in the middle of their cell while jittered
+			// samples may be anywhere within it.
+			cellX, cellY := 0.5, 0.5
+			if p == SampleJittered {
+				cellX, cellY = random.Float64(), random.Float64()
+			}
+
+			offsets = append(offsets, SampleOffset{
+				(float64(col) + cellX) * cellWidth,
+				(float64(row) + cellY) * cellHeight,
+			})
+		}
+	}
+
+	return offsets
+}
+
+// Get the name of the sample pattern.
+func (p SamplePattern) String() string {
+	switch p {
+	case SampleGrid:
+		return "grid"
+	case SampleJittered:
+		return "jittered"
+	case SampleRandom:
+		return "random"
+	}
+
+	return fmt.Sprintf("SamplePattern(%d)", int(p))
+}
+
+// Map a point in the unit square to a point in the unit disk. The mapping
+// preserves the relative areas of regions, so uniformly distributed points in
+// the square remain uniformly distributed in the disk. The center of the square
+// maps to the center of the disk.
+func sampleUnitDisk(u, v float64) (float64, float64) {
+	// Shift the point into the range [-1, 1].
+	x := 2*u - 1
+	y := 2*v - 1
+
+	if x == 0 && y == 0 {
+		return 0, 0
+	}
+
+	// Use the concentric mapping, which maps squares around the center to
+	// circles around the center.
+	var radius, theta float64
+	if math.Abs(x) > math.Abs(y) {
+		radius = x
+		theta = math.Pi / 4 * (y / x)
+	} else {
+		radius = y
+		theta = math.Pi/2 - math.Pi/4*(x/y)
+	}
+
+	return radius * math.Cos(theta), radius * math.Sin(theta)
+}
+
+// Map a point in the unit square to a direction in the hemisphere around a
+// normal vector. Directions are distributed in proportion to the cosine of
+// their angle with the normal, which is the distribution of light reflected
+// by a perfectly diffuse surface. The probability density of a direction is its
+// cosine divided by pi.
+func sampleCosineHemisphere(normal Tuple, u, v float64) Tuple {
+	// Points distributed uniformly in the disk are distributed by cosine when
+	// projected up onto the hemisphere.
+	x, y := sampleUnitDisk(u, v)
+	z := math.Sqrt(math.Max(0, 1-x*x-y*y))
+
+	tangent, bitangent := makeOrthonormalBasis(normal)
+
+	return tangent.Multiply(x).Add(bitangent.Multiply(y)).Add(normal.Multiply(z))
+}
+
+// Choose a direction uniformly from the unit sphere given two random values in
+// the range [0, 1).
+func sampleUniformSphere(u, v float64) Tuple {
+	z := 1 - 2*u
+	radius := math.Sqrt(math.Max(0, 1-z*z))
+	phi := 2 * math.Pi * v
+
+	return MakeVector(radius*math.Cos(phi), radius*math.Sin(phi), z)
+}
+
+// Create two unit vectors that are perpendicular to a normal vector and to each
+// other.
+func makeOrthonormalBasis(normal Tuple) (Tuple, Tuple) {
+	// Cross the normal with whichever axis is furthest from parallel to it.
+	axis := MakeVector(1, 0, 0)
+	if math.Abs(normal.X) > 0.9 {
+		axis = MakeVector(0, 1, 0)
+	}
+
+	tangent := axis.Cross(normal).Normalized()
+	bitangent := normal.Cross(tangent)
+
+	return tangent, bitangent
+}
+
+// Derive the seed of the random numbers used for the samples taken at a point
+// on the canvas, given in pixel units, during a pass of a render. Reseeding for
+// every point makes the samples at a point independent of which other points
+// are rendered and in what order, so the pixels of a region render are the same
+// as they are in a render of the whole image.
+func sampleSeed(seed int64, x, y float64, pass int) int64 {
+	hash := uint64(seed)
+	for _, value := range []uint64{math.Float64bits(x), math.Float64bits(y), uint64(pass)} {
+		hash = splitMix64(hash ^ value)
+	}
+
+	return int64(hash)
+}
+
+// Scramble the bits of a value with the finalizer of the SplitMix64 generator,
+// so that nearby values give unrelated results.
+func splitMix64(value uint64) uint64 {
+	value += 0x9e3779b97f4a7c15
+	value = (value ^ value>>30) * 0xbf58476d1ce4e5b9
+	value = (value ^ value>>27) * 0x94d049bb133111eb
+
+	return value ^ value>>31
+}
+
+// A sample source is a source of random numbers following the SplitMix64
+// generator. Unlike the default source of the rand package, it is cheap to
+// seed, so it can be reseeded for every point on the canvas.
+type sampleSource struct {
+	state uint64
+}
+
+// Create a generator of random numbers for samples backed by a sample source.
+func makeSampleRandom(seed int64) *rand.Rand {
+	return rand.New(&sampleSource{state: uint64(seed)})
+}
+
+// Restart the sequence of random numbers from a seed.
+func (s *sampleSource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// Get the next random number in the range [0, 2^64).
+func (s *sampleSource) Uint64() uint64 {
+	value := splitMix64(s.state)
+	s.state += 0x9e3779b97f4a7c15
+
+	return value
+}
+
+// Get the next random number in the range [0, 2^63).
+func (s *sampleSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
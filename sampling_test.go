@@ -0,0 +1,164 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseSamplePattern(t *testing.T) {
+	testCases := []struct {
+		name    string
+		want    SamplePattern
+		wantErr bool
+	}{
+		{"grid", SampleGrid, false},
+		{"jittered", SampleJittered, false},
+		{"stratified", SampleJittered, false},
+		{"random", SampleRandom, false},
+		{"bogus", SampleGrid, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSamplePattern(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error = %v, got %v", tt.wantErr, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Expected pattern %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSamplePattern_Offsets_Grid(t *testing.T) {
+	want := []SampleOffset{
+		{0.25, 0.25},
+		{0.75, 0.25},
+		{0.25, 0.75},
+		{0.75, 0.75},
+	}
+
+	got := SampleGrid.Offsets(4, rand.New(rand.NewSource(0)))
+
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d offsets, got %d", len(want), len(got))
+	}
+
+	for i := range want {
+		if !Float64Equal(want[i].X, got[i].X) || !Float64Equal(want[i].Y, got[i].Y) {
+			t.Errorf("Expected offset %d to be %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestSamplePattern_Offsets_SingleGridSampleIsCentered(t *testing.T) {
+	got := SampleGrid.Offsets(1, rand.New(rand.NewSource(0)))
+
+	if len(got) != 1 || got[0] != (SampleOffset{0.5, 0.5}) {
+		t.Errorf("Expected a single centered offset, got %v", got)
+	}
+}
+
+func TestSamplePattern_Offsets_Count(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern SamplePattern
+		count   int
+		want    int
+	}{
+		{"grid square", SampleGrid, 9, 9},
+		{"grid rounds up", SampleGrid, 5, 6},
+		{"jittered square", SampleJittered, 16, 16},
+		{"random exact", SampleRandom, 5, 5},
+		{"zero is one sample", SampleRandom, 0, 1},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.pattern.Offsets(tt.count, rand.New(rand.NewSource(0)))); got != tt.want {
+				t.Errorf("Expected %d offsets, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+// Every jittered sample should stay within its own cell of the grid.
+func TestSamplePattern_Offsets_JitteredStratified(t *testing.T) {
+	offsets := SampleJittered.Offsets(4, rand.New(rand.NewSource(42)))
+
+	for i, offset := range offsets {
+		col := float64(i % 2)
+		row := float64(i / 2)
+
+		if offset.X < col*0.5 || offset.X >= (col+1)*0.5 ||
+			offset.Y < row*0.5 || offset.Y >= (row+1)*0.5 {
+			t.Errorf("Expected offset %d to lie in cell (%v, %v), got %v", i, col, row, offset)
+		}
+	}
+}
+
+func TestSamplePattern_Offsets_Deterministic(t *testing.T) {
+	a := SampleRandom.Offsets(8, rand.New(rand.NewSource(7)))
+	b := SampleRandom.Offsets(8, rand.New(rand.NewSource(7)))
+
+	for i := range a {
+		if a[i] != b[i] {
+			t.Errorf("Expected offset %d to match for the same seed; got %v and %v", i, a[i], b[i])
+		}
+	}
+}
+
+func TestSampleUnitDisk(t *testing.T) {
+	testCases := []struct {
+		name  string
+		u     float64
+		v     float64
+		wantX float64
+		wantY float64
+	}{
+		{"center", 0.5, 0.5, 0, 0},
+		{"right", 1, 0.5, 1, 0},
+		{"left", 0, 0.5, -1, 0},
+		{"top", 0.5, 1, 0, 1},
+		{"bottom", 0.5, 0, 0, -1},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			x, y := sampleUnitDisk(tt.u, tt.v)
+			if !Float64Equal(tt.wantX, x) || !Float64Equal(tt.wantY, y) {
+				t.Errorf("Expected (%v, %v), got (%v, %v)", tt.wantX, tt.wantY, x, y)
+			}
+		})
+	}
+}
+
+func TestSampleUnitDisk_StaysInDisk(t *testing.T) {
+	random := rand.New(rand.NewSource(3))
+	for i := 0; i < 1000; i++ {
+		x, y := sampleUnitDisk(random.Float64(), random.Float64())
+		if x*x+y*y > 1+floatEpsilon {
+			t.Fatalf("Expected point inside unit disk, got (%v, %v)", x, y)
+		}
+	}
+}
+
+func TestSampleSource_SeedRestartsSequence(t *testing.T) {
+	random := makeSampleRandom(5)
+	first := []float64{random.Float64(), random.Float64(), random.Float64()}
+
+	random.Seed(5)
+	for i, want := range first {
+		if got := random.Float64(); got != want {
+			t.Errorf("Expected value %d to be %v after reseeding, got %v", i, want, got)
+		}
+	}
+}
+
+func TestSampleSource_Range(t *testing.T) {
+	random := makeSampleRandom(11)
+	for i := 0; i < 1000; i++ {
+		if value := random.Float64(); value < 0 || value >= 1 {
+			t.Fatalf("Expected value in [0, 1), got %v", value)
+		}
+	}
+}
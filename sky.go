@@ -0,0 +1,226 @@
+package main
+
+import "math"
+
+// The brightness given to a luminance of one thousand candela per square meter
+// of the sky model. A clear sky is a few thousand candela per square meter, so
+// this keeps the sky in the same range as the colors of other environments.
+const skyLuminanceScale = 0.05
+
+// A sky environment is a clear daytime sky computed analytically from the
+// position of the sun and the haziness of the atmosphere, following the model
+// in "A Practical Analytic Model for Daylight" by Preetham, Shirley, and Smits.
+// The sky is brightest around the sun and towards the horizon, and turns from
+// deep blue to white as the atmosphere gets hazier. The sun itself is not part
+// of the sky, so it should be added to the world as the directional light
+// returned by Sun.
+type SkyEnvironment struct {
+	// The direction pointing towards the sun.
+	SunDirection Tuple
+	// The haziness of the atmosphere: 2 is a very clear sky, 3 is a typical
+	// clear sky, and 10 is a hazy one.
+	Turbidity float64
+	// The factor to scale the sky's and the sun's colors by.
+	Strength float64
+	// The color seen below the horizon.
+	GroundColor Color
+}
+
+// Create a clear sky lit by a sun in the given direction.
+func MakeSkyEnvironment(sunDirection Tuple, turbidity float64) SkyEnvironment {
+	return SkyEnvironment{
+		SunDirection: sunDirection.Normalized(),
+		Turbidity:    turbidity,
+		Strength:     1,
+		GroundColor:  MakeColor(0, 0, 0),
+	}
+}
+
+// Get the direction pointing towards the sun from its elevation above the
+// horizon and its azimuth, both in radians. An azimuth of zero puts the sun in
+// the +z direction, and it moves towards +x as the azimuth increases.
+func MakeSunDirection(elevation, azimuth float64) Tuple {
+	return MakeVector(
+		math.Sin(azimuth)*math.Cos(elevation),
+		math.Sin(elevation),
+		math.Cos(azimuth)*math.Cos(elevation),
+	)
+}
+
+// Get the color of the sky seen when looking in a direction.
+func (e SkyEnvironment) ColorInDirection(direction Tuple) Color {
+	direction = direction.Normalized()
+	if direction.Y < 0 {
+		return e.GroundColor
+	}
+
+	turbidity := e.Turbidity
+	sunTheta := e.sunZenithAngle()
+	// The model breaks down at the horizon, where the path through the
+	// atmosphere becomes infinitely long.
+	cosTheta := math.Max(direction.Y, 1e-3)
+	cosGamma := math.Max(-1, math.Min(1, direction.Dot(e.SunDirection)))
+	gamma := math.Acos(cosGamma)
+
+	luminance := skyZenithLuminance(turbidity, sunTheta) *
+		perezRatio(skyLuminanceCoefficients(turbidity), cosTheta, gamma, sunTheta)
+	x := skyZenithChromaticity(turbidity, sunTheta, skyZenithX) *
+		perezRatio(skyXCoefficients(turbidity), cosTheta, gamma, sunTheta)
+	y := skyZenithChromaticity(turbidity, sunTheta, skyZenithY) *
+		perezRatio(skyYCoefficients(turbidity), cosTheta, gamma, sunTheta)
+
+	return chromaticityToColor(x, y, luminance*skyLuminanceScale*e.Strength)
+}
+
+// Get the directional light of the sun matching the sky. Sunlight loses more
+// blue than red on its way through the atmosphere, so the sun turns yellow and
+// then red as it gets lower and as the atmosphere gets hazier. The sun gives
+// off no light once it has set.
+func (e SkyEnvironment) Sun() DirectionalLight {
+	if e.SunDirection.Y <= 0 {
+		return MakeDirectionalLight(e.SunDirection, MakeColor(0, 0, 0))
+	}
+
+	theta := e.sunZenithAngle()
+	// The relative length of the path sunlight takes through the atmosphere,
+	// from Kasten and Young's formula.
+	airMass := 1 / (math.Cos(theta) + 0.15*math.Pow(93.885-theta*180/math.Pi, -1.253))
+
+	// The wavelengths of red, green, and blue light in micrometers.
+	red := sunTransmittance(0.680, e.Turbidity, airMass)
+	green := sunTransmittance(0.550, e.Turbidity, airMass)
+	blue := sunTransmittance(0.440, e.Turbidity, airMass)
+
+	return MakeDirectionalLight(e.SunDirection, MakeColor(red, green, blue).Multiply(e.Strength))
+}
+
+// Get the angle between the sun and straight up. The sky model doesn't cover
+// a sun below the horizon, so the sky keeps its sunset colors after that.
+func (e SkyEnvironment) sunZenithAngle() float64 {
+	return math.Acos(math.Max(0, math.Min(1, e.SunDirection.Y)))
+}
+
+// The fraction of light of a wavelength in micrometers that passes through the
+// atmosphere, accounting for scattering by air molecules and by haze.
+func sunTransmittance(wavelength, turbidity, airMass float64) float64 {
+	rayleigh := 0.008735 * math.Pow(wavelength, -4.08)
+	// Angstrom's turbidity coefficient, fit to the turbidity of the model.
+	beta := math.Max(0, 0.04608*turbidity-0.04586)
+	aerosol := beta * math.Pow(wavelength, -1.3)
+
+	return math.Exp(-(rayleigh + aerosol) * airMass)
+}
+
+// The coefficients of the Perez sky luminance distribution.
+type perezCoefficients struct {
+	a, b, c, d, e float64
+}
+
+// Get the coefficients of the distribution of luminance across the sky.
+func skyLuminanceCoefficients(turbidity float64) perezCoefficients {
+	return perezCoefficients{
+		a: 0.1787*turbidity - 1.4630,
+		b: -0.3554*turbidity + 0.4275,
+		c: -0.0227*turbidity + 5.3251,
+		d: 0.1206*turbidity - 2.5771,
+		e: -0.0670*turbidity + 0.3703,
+	}
+}
+
+// Get the coefficients of the distribution of the x chromaticity across the
+// sky.
+func skyXCoefficients(turbidity float64) perezCoefficients {
+	return perezCoefficients{
+		a: -0.0193*turbidity - 0.2592,
+		b: -0.0665*turbidity + 0.0008,
+		c: -0.0004*turbidity + 0.2125,
+		d: -0.0641*turbidity - 0.8989,
+		e: -0.0033*turbidity + 0.0452,
+	}
+}
+
+// Get the coefficients of the distribution of the y chromaticity across the
+// sky.
+func skyYCoefficients(turbidity float64) perezCoefficients {
+	return perezCoefficients{
+		a: -0.0167*turbidity - 0.2608,
+		b: -0.0950*turbidity + 0.0092,
+		c: -0.0079*turbidity + 0.2102,
+		d: -0.0441*turbidity - 1.6537,
+		e: -0.0109*turbidity + 0.0529,
+	}
+}
+
+// The Perez function of a direction with the given cosine with straight up and
+// angle to the sun.
+func (p perezCoefficients) evaluate(cosTheta, gamma float64) float64 {
+	cosGamma := math.Cos(gamma)
+
+	return (1 + p.a*math.Exp(p.b/cosTheta)) *
+		(1 + p.c*math.Exp(p.d*gamma) + p.e*cosGamma*cosGamma)
+}
+
+// Get the value of a direction relative to the value straight up, where the
+// angle to the sun is the sun's zenith angle.
+func perezRatio(p perezCoefficients, cosTheta, gamma, sunTheta float64) float64 {
+	return p.evaluate(cosTheta, gamma) / p.evaluate(1, sunTheta)
+}
+
+// Get the luminance of the sky straight up in thousands of candela per square
+// meter.
+func skyZenithLuminance(turbidity, sunTheta float64) float64 {
+	chi := (4.0/9.0 - turbidity/120) * (math.Pi - 2*sunTheta)
+
+	return math.Max(0, (4.0453*turbidity-4.9710)*math.Tan(chi)-0.2155*turbidity+2.4192)
+}
+
+// The coefficients of the polynomials in the turbidity and the sun's zenith
+// angle giving the chromaticity of the sky straight up. The rows are the
+// coefficients of the turbidity squared, the turbidity, and the constant term,
+// and the columns are the coefficients of the cubed, squared, linear, and
+// constant terms of the angle.
+var (
+	skyZenithX = [3][4]float64{
+		{0.00166, -0.00375, 0.00209, 0},
+		{-0.02903, 0.06377, -0.03202, 0.00394},
+		{0.11693, -0.21196, 0.06052, 0.25886},
+	}
+	skyZenithY = [3][4]float64{
+		{0.00275, -0.00610, 0.00317, 0},
+		{-0.04214, 0.08970, -0.04153, 0.00516},
+		{0.15346, -0.26756, 0.06670, 0.26688},
+	}
+)
+
+// Get a chromaticity coordinate of the sky straight up.
+func skyZenithChromaticity(turbidity, sunTheta float64, coefficients [3][4]float64) float64 {
+	turbidities := [3]float64{turbidity * turbidity, turbidity, 1}
+	angles := [4]float64{sunTheta * sunTheta * sunTheta, sunTheta * sunTheta, sunTheta, 1}
+
+	value := 0.0
+	for i, t := range turbidities {
+		for j, a := range angles {
+			value += coefficients[i][j] * t * a
+		}
+	}
+
+	return value
+}
+
+// Convert a color given by its CIE xy chromaticity and luminance to linear
+// sRGB. Colors outside of the sRGB gamut are clamped to it.
+func chromaticityToColor(x, y, luminance float64) Color {
+	if y <= 0 {
+		return MakeColor(0, 0, 0)
+	}
+
+	cieX := x / y * luminance
+	cieY := luminance
+	cieZ := (1 - x - y) / y * luminance
+
+	return MakeColor(
+		math.Max(0, 3.2406*cieX-1.5372*cieY-0.4986*cieZ),
+		math.Max(0, -0.9689*cieX+1.8758*cieY+0.0415*cieZ),
+		math.Max(0, 0.0557*cieX-0.2040*cieY+1.0570*cieZ),
+	)
+}
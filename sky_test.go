@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMakeSunDirection(t *testing.T) {
+	testCases := []struct {
+		name      string
+		elevation float64
+		azimuth   float64
+		want      Tuple
+	}{
+		{"on the horizon ahead", 0, 0, MakeVector(0, 0, 1)},
+		{"on the horizon to the right", 0, math.Pi / 2, MakeVector(1, 0, 0)},
+		{"on the horizon behind", 0, math.Pi, MakeVector(0, 0, -1)},
+		{"straight up", math.Pi / 2, 0, MakeVector(0, 1, 0)},
+		{"halfway up", math.Pi / 4, math.Pi / 2, MakeVector(math.Sqrt2/2, math.Sqrt2/2, 0)},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MakeSunDirection(tt.elevation, tt.azimuth); !tt.want.Equals(got) {
+				t.Errorf("Expected direction %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSkyEnvironment_ColorInDirection(t *testing.T) {
+	sky := MakeSkyEnvironment(MakeSunDirection(math.Pi/6, math.Pi), 3)
+
+	zenith := sky.ColorInDirection(MakeVector(0, 1, 0))
+	if zenith.Blue() <= zenith.Red() {
+		t.Errorf("Expected the sky straight up to be blue, got %v", zenith)
+	}
+
+	towardsSun := sky.ColorInDirection(MakeSunDirection(math.Pi/6+0.1, math.Pi))
+	awayFromSun := sky.ColorInDirection(MakeSunDirection(math.Pi/6+0.1, 0))
+	if towardsSun.Luminance() <= awayFromSun.Luminance() {
+		t.Errorf("Expected the sky near the sun to be brighter than %v, got %v", awayFromSun, towardsSun)
+	}
+
+	sky.GroundColor = MakeColor(0.1, 0.2, 0.3)
+	if got := sky.ColorInDirection(MakeVector(0.5, -0.5, 0)); !got.Equals(sky.GroundColor) {
+		t.Errorf("Expected the ground color %v below the horizon, got %v", sky.GroundColor, got)
+	}
+
+	brighter := sky
+	brighter.Strength = 2
+	want := zenith.Multiply(2)
+	if got := brighter.ColorInDirection(MakeVector(0, 1, 0)); !want.Equals(got) {
+		t.Errorf("Expected color %v, got %v", want, got)
+	}
+}
+
+func TestSkyEnvironment_ColorInDirection_Turbidity(t *testing.T) {
+	clear := MakeSkyEnvironment(MakeSunDirection(math.Pi/4, math.Pi), 2)
+	hazy := MakeSkyEnvironment(MakeSunDirection(math.Pi/4, math.Pi), 8)
+
+	// Haze scatters every color alike, washing the blue out of the sky.
+	up := MakeVector(0, 1, 0)
+	clearColor := clear.ColorInDirection(up)
+	hazyColor := hazy.ColorInDirection(up)
+	if clearColor.Blue()/clearColor.Red() <= hazyColor.Blue()/hazyColor.Red() {
+		t.Errorf("Expected a clear sky %v to be bluer than a hazy sky %v", clearColor, hazyColor)
+	}
+}
+
+func TestSkyEnvironment_Sun(t *testing.T) {
+	testCases := []struct {
+		name      string
+		elevation float64
+		turbidity float64
+	}{
+		{"high sun in clear sky", math.Pi / 3, 2},
+		{"high sun in hazy sky", math.Pi / 3, 8},
+		{"low sun in clear sky", math.Pi / 18, 2},
+		{"low sun in hazy sky", math.Pi / 18, 8},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sky := MakeSkyEnvironment(MakeSunDirection(tt.elevation, math.Pi/2), tt.turbidity)
+			sun := sky.Sun()
+
+			if !sun.Direction.Equals(sky.SunDirection) {
+				t.Errorf("Expected sun direction %v, got %v", sky.SunDirection, sun.Direction)
+			}
+
+			intensity := sun.Intensity
+			if !(intensity.Red() <= 1 && intensity.Red() >= intensity.Green() && intensity.Green() >= intensity.Blue() && intensity.Blue() > 0) {
+				t.Errorf("Expected a yellowish sun no brighter than 1, got %v", intensity)
+			}
+		})
+	}
+
+	high := MakeSkyEnvironment(MakeSunDirection(math.Pi/3, 0), 3).Sun().Intensity
+	low := MakeSkyEnvironment(MakeSunDirection(math.Pi/18, 0), 3).Sun().Intensity
+	if low.Blue()/low.Red() >= high.Blue()/high.Red() {
+		t.Errorf("Expected a low sun %v to be redder than a high sun %v", low, high)
+	}
+
+	set := MakeSkyEnvironment(MakeSunDirection(-0.1, 0), 3).Sun()
+	if set.IsOn() {
+		t.Errorf("Expected a sun below the horizon to give off no light, got %v", set.Intensity)
+	}
+}
@@ -5,6 +5,10 @@ import "math"
 type Sphere struct {
 	material  Material
 	transform Matrix
+
+	// The motion of the sphere over time. Spheres without motion are always
+	// placed using their transform.
+	motion *AnimatedTransform
 }
 
 func MakeSphere() Sphere {
@@ -21,8 +25,23 @@ func MakeSphereTransformed(transform Matrix) Sphere {
 	}
 }
 
-// Get the values of t at which the given ray intersects the sphere.
+// Create a sphere that moves over time. The sphere's transform is its placement
+// at the start of the motion.
+func MakeSphereAnimated(motion AnimatedTransform) Sphere {
+	return Sphere{
+		material:  MakeMaterial(),
+		transform: motion.TransformAt(motion.StartTime),
+		motion:    &motion,
+	}
+}
+
+// Get the values of t at which the given ray intersects the sphere. Moving
+// spheres are first placed according to the time of the ray.
 func (s Sphere) Intersect(ray Ray) Intersections {
+	if s.motion != nil {
+		s = s.atTime(ray.Time)
+	}
+
 	// Apply the sphere's transformations by applying their inverse to the ray.
 	ray = ray.Transform(s.transform.Inverted())
 
@@ -71,7 +90,55 @@ func (s Sphere) NormalAt(worldPoint Tuple) Tuple {
 	return worldNormal.Normalized()
 }
 
+// Get the tangent of the sphere at a point on its surface given in world
+// space. The tangent points east around the sphere's y axis, which is the
+// direction the u coordinate of the spherical mapping increases in.
+func (s Sphere) TangentAt(worldPoint Tuple) Tuple {
+	objectPoint := s.transform.Inverted().TupleMultiply(worldPoint)
+	objectTangent := MakeVector(-objectPoint.Z, 0, objectPoint.X)
+	// East is undefined at the poles, so any direction around them will do.
+	if objectTangent.Magnitude() < floatEpsilon {
+		objectTangent = MakeVector(1, 0, 0)
+	}
+
+	return s.transform.TupleMultiply(objectTangent).Normalized()
+}
+
+// Choose a random point on the surface of the sphere, uniformly by area. The
+// normal at the point and the probability density of choosing it per unit of
+// area are also returned. Moving spheres are placed where they are at the given
+// time.
+func (s Sphere) SampleSurface(u, v, time float64) (Tuple, Tuple, float64) {
+	if s.motion != nil {
+		s = s.atTime(time)
+	}
+
+	objectNormal := sampleUniformSphere(u, v)
+	objectPoint := MakePoint(objectNormal.X, objectNormal.Y, objectNormal.Z)
+	point := s.transform.TupleMultiply(objectPoint)
+
+	worldNormal := s.transform.Inverted().Transposed().TupleMultiply(objectNormal)
+	worldNormal.W = 0
+
+	// The transform stretches each patch of the unit sphere's surface by the
+	// determinant of the transform times the length of the untransformed
+	// normal, which handles spheres that are scaled unevenly.
+	stretch := math.Abs(s.transform.Determinant()) * worldNormal.Magnitude()
+
+	return point, worldNormal.Normalized(), 1 / (4 * math.Pi * stretch)
+}
+
 // Get the sphere's transformation matrix.
 func (s Sphere) Transform() Matrix {
 	return s.transform
 }
+
+// Get a stationary copy of the sphere placed where it is at the given time.
+// The intersections of a moving sphere refer to such a copy so that the normal
+// at the intersection is computed from the same placement that was hit.
+func (s Sphere) atTime(time float64) Sphere {
+	return Sphere{
+		material:  s.material,
+		transform: s.motion.TransformAt(time),
+	}
+}
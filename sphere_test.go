@@ -157,3 +157,137 @@ func TestSphere_NormalAt(t *testing.T) {
 		})
 	}
 }
+
+func TestSphere_TangentAt(t *testing.T) {
+	testCases := []struct {
+		name   string
+		sphere Sphere
+		point  Tuple
+		want   Tuple
+	}{
+		{"front", MakeSphere(), MakePoint(0, 0, -1), MakeVector(1, 0, 0)},
+		{"right", MakeSphere(), MakePoint(1, 0, 0), MakeVector(0, 0, 1)},
+		{"upper front", MakeSphere(), MakePoint(0, math.Sqrt2/2, -math.Sqrt2/2), MakeVector(1, 0, 0)},
+		{"pole", MakeSphere(), MakePoint(0, 1, 0), MakeVector(1, 0, 0)},
+		{
+			"translated",
+			MakeSphereTransformed(MakeTranslation(0, 1, 0)),
+			MakePoint(0, 1, -1),
+			MakeVector(1, 0, 0),
+		},
+		{
+			"rotated",
+			MakeSphereTransformed(MakeZRotation(math.Pi / 2)),
+			MakePoint(0, 0, -1),
+			MakeVector(0, 1, 0),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.sphere.TangentAt(tt.point)
+			if !tt.want.Equals(got) {
+				t.Errorf("Expected tangent %v, got %v", tt.want, got)
+			}
+
+			if normal := tt.sphere.NormalAt(tt.point); !Float64Equal(0, normal.Dot(got)) {
+				t.Errorf("Expected tangent %v to be perpendicular to normal %v", got, normal)
+			}
+		})
+	}
+}
+
+func TestSphere_Intersect_Animated(t *testing.T) {
+	start := MakeKeyframe()
+	end := MakeKeyframe()
+	end.Translation = MakeVector(0, 4, 0)
+	sphere := MakeSphereAnimated(MakeAnimatedTransform(start, end))
+
+	testCases := []struct {
+		name    string
+		time    float64
+		wantHit bool
+	}{
+		{"start", 0, false},
+		{"middle", 0.5, false},
+		{"end", 1, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			ray := MakeRayAtTime(MakePoint(0, 4, -5), MakeVector(0, 0, 1), tt.time)
+			intersections := sphere.Intersect(ray)
+
+			if got := len(intersections) > 0; got != tt.wantHit {
+				t.Fatalf("Expected hit = %v, got %v", tt.wantHit, intersections)
+			}
+
+			// The normal should be computed using the sphere's placement at
+			// the time of the ray.
+			if tt.wantHit {
+				normal := intersections[0].Object.NormalAt(ray.Position(intersections[0].T))
+				if want := MakeVector(0, 0, -1); !want.Equals(normal) {
+					t.Errorf("Expected normal %v, got %v", want, normal)
+				}
+			}
+		})
+	}
+}
+
+func TestMakeSphereAnimated(t *testing.T) {
+	start := MakeKeyframe()
+	start.Translation = MakeVector(1, 0, 0)
+	sphere := MakeSphereAnimated(MakeAnimatedTransform(start, MakeKeyframe()))
+
+	if want := MakeTranslation(1, 0, 0); !want.Equals(sphere.Transform()) {
+		t.Errorf("Expected transform %v, got %v", want, sphere.Transform())
+	}
+}
+
+func TestSphere_SampleSurface(t *testing.T) {
+	testCases := []struct {
+		name      string
+		transform Matrix
+		// The surface area of the transformed sphere.
+		area float64
+	}{
+		{"unit sphere", IdentityMatrix4, 4 * math.Pi},
+		{"scaled sphere", MakeScale(2, 2, 2), 16 * math.Pi},
+		{
+			// The area of a prolate spheroid with an equatorial radius of 1
+			// and a polar radius of 2.
+			"stretched sphere",
+			MakeTranslation(1, 2, 3).Multiply(MakeScale(1, 1, 2)),
+			2 * math.Pi * (1 + 2/math.Sqrt(0.75)*math.Asin(math.Sqrt(0.75))),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			sphere := MakeSphereTransformed(tt.transform)
+			inverse := tt.transform.Inverted()
+
+			// Averaging the inverse of the density estimates the area.
+			steps := 200
+			area := 0.0
+			for i := 0; i < steps; i++ {
+				for j := 0; j < steps; j++ {
+					u := (float64(i) + 0.5) / float64(steps)
+					v := (float64(j) + 0.5) / float64(steps)
+					point, normal, pdf := sphere.SampleSurface(u, v, 0)
+
+					if got := inverse.TupleMultiply(point).Subtract(MakePoint(0, 0, 0)).Magnitude(); !Float64Equal(got, 1) {
+						t.Fatalf("Expected point %v to be on the surface", point)
+					}
+					if want := sphere.NormalAt(point); !want.Equals(normal) {
+						t.Fatalf("Expected normal %v at %v, got %v", want, point, normal)
+					}
+
+					area += 1 / pdf
+				}
+			}
+			area /= float64(steps * steps)
+
+			if math.Abs(area-tt.area) > tt.area*1e-3 {
+				t.Errorf("Expected an area of %v, got %v", tt.area, area)
+			}
+		})
+	}
+}
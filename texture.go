@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// A texture varies the color of a material across the surface of an object.
+type Texture interface {
+	// Get the color of the texture at a point given in object space.
+	ColorAt(point Tuple) Color
+}
+
+// A texture wrap mode determines the color of an image texture outside of the
+// range [0, 1] of texture coordinates.
+type TextureWrap int
+
+const (
+	// The image repeats endlessly in every direction.
+	TextureWrapRepeat TextureWrap = iota
+	// The pixels at the edges of the image stretch out endlessly.
+	TextureWrapClamp
+)
+
+// Parse the name of a texture wrap mode as it would be given on the command
+// line.
+func ParseTextureWrap(name string) (TextureWrap, error) {
+	switch name {
+	case "repeat":
+		return TextureWrapRepeat, nil
+	case "clamp":
+		return TextureWrapClamp, nil
+	}
+
+	return TextureWrapRepeat, fmt.Errorf("unknown texture wrap mode '%s'", name)
+}
+
+// Get the index of the pixel used for an index that may lie outside of a row
+// or column of the given length.
+func (w TextureWrap) index(index, length int) int {
+	if w == TextureWrapClamp {
+		return int(math.Max(0, math.Min(float64(length-1), float64(index))))
+	}
+
+	index %= length
+	if index < 0 {
+		index += length
+	}
+
+	return index
+}
+
+// An image texture takes its colors from an image laid over the surface of an
+// object by a UV mapping. Colors are interpolated between the four pixels
+// nearest to a point, so that magnified images are smooth instead of blocky.
+type ImageTexture struct {
+	// The image of the texture. Its colors are used as they are, so images
+	// encoded with the sRGB transfer function should be decoded first.
+	Image Canvas
+	// The mapping from points on the surface to places in the image.
+	Mapping UVMapping
+	// How the image is extended beyond its edges.
+	Wrap TextureWrap
+}
+
+// Create a texture from an image laid over the surface of an object with the
+// given mapping. The image repeats beyond its edges.
+func MakeImageTexture(image Canvas, mapping UVMapping) *ImageTexture {
+	return &ImageTexture{
+		Image:   image,
+		Mapping: mapping,
+		Wrap:    TextureWrapRepeat,
+	}
+}
+
+// Get the color of the texture at a point given in object space.
+func (t *ImageTexture) ColorAt(point Tuple) Color {
+	u, v := t.Mapping.Map(point)
+
+	return t.ColorAtUV(u, v)
+}
+
+// Get the color of the texture at texture coordinates, where (0, 0) is the
+// bottom left corner of the image and (1, 1) is the top right corner.
+func (t *ImageTexture) ColorAtUV(u, v float64) Color {
+	width, height := t.Image.Width, t.Image.Height
+	if width == 0 || height == 0 {
+		return MakeColor(0, 0, 0)
+	}
+
+	// Find the position relative to the centers of the pixels.
+	x := u*float64(width) - 0.5
+	y := (1-v)*float64(height) - 0.5
+	left, top := math.Floor(x), math.Floor(y)
+	fractionX, fractionY := x-left, y-top
+
+	pixel := func(x, y float64) Color {
+		return t.Image.GetPixel(t.Wrap.index(int(x), width), t.Wrap.index(int(y), height))
+	}
+
+	upper := pixel(left, top).Multiply(1 - fractionX).Add(pixel(left+1, top).Multiply(fractionX))
+	lower := pixel(left, top+1).Multiply(1 - fractionX).Add(pixel(left+1, top+1).Multiply(fractionX))
+
+	return upper.Multiply(1 - fractionY).Add(lower.Multiply(fractionY))
+}
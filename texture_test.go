@@ -0,0 +1,80 @@
+package main
+
+import "testing"
+
+// Make a 2x2 image with a different color in each corner.
+func makeCornerImage() Canvas {
+	image := MakeCanvas(2, 2)
+	image.SetPixel(0, 0, MakeColor(1, 0, 0))
+	image.SetPixel(1, 0, MakeColor(0, 1, 0))
+	image.SetPixel(0, 1, MakeColor(0, 0, 1))
+	image.SetPixel(1, 1, MakeColor(1, 1, 1))
+
+	return image
+}
+
+func TestImageTexture_ColorAtUV(t *testing.T) {
+	testCases := []struct {
+		name string
+		wrap TextureWrap
+		u    float64
+		v    float64
+		want Color
+	}{
+		{"top left pixel center", TextureWrapRepeat, 0.25, 0.75, MakeColor(1, 0, 0)},
+		{"top right pixel center", TextureWrapRepeat, 0.75, 0.75, MakeColor(0, 1, 0)},
+		{"bottom left pixel center", TextureWrapRepeat, 0.25, 0.25, MakeColor(0, 0, 1)},
+		{"bottom right pixel center", TextureWrapRepeat, 0.75, 0.25, MakeColor(1, 1, 1)},
+		{"between top pixels", TextureWrapRepeat, 0.5, 0.75, MakeColor(0.5, 0.5, 0)},
+		{"between all pixels", TextureWrapRepeat, 0.5, 0.5, MakeColor(0.5, 0.5, 0.5)},
+		{"quarter of the way between top pixels", TextureWrapRepeat, 0.375, 0.75, MakeColor(0.75, 0.25, 0)},
+		{"repeated", TextureWrapRepeat, 1.25, -0.75, MakeColor(0, 0, 1)},
+		{"repeated across the left edge", TextureWrapRepeat, 0, 0.75, MakeColor(0.5, 0.5, 0)},
+		{"clamped across the left edge", TextureWrapClamp, 0, 0.75, MakeColor(1, 0, 0)},
+		{"clamped far away", TextureWrapClamp, 7, -3, MakeColor(1, 1, 1)},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			texture := MakeImageTexture(makeCornerImage(), PlanarMapping{})
+			texture.Wrap = tt.wrap
+
+			if got := texture.ColorAtUV(tt.u, tt.v); !tt.want.Equals(got) {
+				t.Errorf("Expected color %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestImageTexture_ColorAt(t *testing.T) {
+	texture := MakeImageTexture(makeCornerImage(), PlanarMapping{})
+
+	// The planar mapping places the point at (0.75, 0.25) in the texture.
+	want := MakeColor(1, 1, 1)
+	if got := texture.ColorAt(MakePoint(0.75, 3, 0.25)); !want.Equals(got) {
+		t.Errorf("Expected color %v, got %v", want, got)
+	}
+}
+
+func TestParseTextureWrap(t *testing.T) {
+	testCases := []struct {
+		name    string
+		want    TextureWrap
+		wantErr bool
+	}{
+		{"repeat", TextureWrapRepeat, false},
+		{"clamp", TextureWrapClamp, false},
+		{"bogus", TextureWrapRepeat, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTextureWrap(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error = %v, got %v", tt.wantErr, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Expected wrap mode %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// A tone mapping operator compresses the unbounded intensities of a rendered
+// image into the range [0, 1] that can be displayed.
+type ToneMap int
+
+const (
+	// Intensities above 1 are clipped, which blows out highlights.
+	ToneMapClamp ToneMap = iota
+	// Intensities are compressed with x / (1 + x), which never quite reaches
+	// full intensity.
+	ToneMapReinhard
+	// Reinhard's operator adjusted so that the white point maps to full
+	// intensity.
+	ToneMapExtendedReinhard
+	// An approximation of the filmic curve used by the Academy Color Encoding
+	// System, which gives a pleasing contrast and soft highlights.
+	ToneMapACES
+)
+
+// Parse the name of a tone mapping operator as it would be given on the command
+// line.
+func ParseToneMap(name string) (ToneMap, error) {
+	switch name {
+	case "clamp":
+		return ToneMapClamp, nil
+	case "reinhard":
+		return ToneMapReinhard, nil
+	case "extended-reinhard":
+		return ToneMapExtendedReinhard, nil
+	case "aces":
+		return ToneMapACES, nil
+	}
+
+	return ToneMapClamp, fmt.Errorf("unknown tone mapping operator '%s'", name)
+}
+
+// Get the name of the tone mapping operator.
+func (t ToneMap) String() string {
+	switch t {
+	case ToneMapClamp:
+		return "clamp"
+	case ToneMapReinhard:
+		return "reinhard"
+	case ToneMapExtendedReinhard:
+		return "extended-reinhard"
+	case ToneMapACES:
+		return "aces"
+	}
+
+	return fmt.Sprintf("ToneMap(%d)", int(t))
+}
+
+// An output transform converts the linear intensities of a rendered image into
+// the display values that are quantized by low dynamic range image formats.
+// Exposure is applied first, then tone mapping, then the sRGB transfer
+// function.
+type OutputTransform struct {
+	// The exposure adjustment in stops. Each stop doubles the brightness.
+	Exposure float64
+	// The operator that compresses intensities into the range [0, 1].
+	ToneMap ToneMap
+	// The intensity that maps to full white with the extended Reinhard
+	// operator. White points that aren't positive fall back to the plain
+	// Reinhard operator, which never reaches full white.
+	WhitePoint float64
+	// Encode values with the sRGB transfer function, which is what displays
+	// expect. Without it, values are written linearly and look too dark.
+	SRGB bool
+}
+
+// Create an output transform that writes intensities linearly and clips them
+// to the range [0, 1].
+func MakeOutputTransform() OutputTransform {
+	return OutputTransform{
+		Exposure:   0,
+		ToneMap:    ToneMapClamp,
+		WhitePoint: 4,
+		SRGB:       false,
+	}
+}
+
+// Transform a linear color into display values in the range [0, 1].
+func (t OutputTransform) Apply(color Color) Color {
+	exposed := color.Multiply(math.Pow(2, t.Exposure))
+
+	return MakeColor(
+		t.applyChannel(exposed.Red()),
+		t.applyChannel(exposed.Green()),
+		t.applyChannel(exposed.Blue()),
+	)
+}
+
+func (t OutputTransform) applyChannel(value float64) float64 {
+	value = math.Max(0, value)
+
+	switch t.ToneMap {
+	case ToneMapReinhard:
+		value = value / (1 + value)
+	case ToneMapExtendedReinhard:
+		if t.WhitePoint > 0 {
+			value = value * (1 + value/(t.WhitePoint*t.WhitePoint)) / (1 + value)
+		} else {
+			value = value / (1 + value)
+		}
+	case ToneMapACES:
+		value = (value * (2.51*value + 0.03)) / (value*(2.43*value+0.59) + 0.14)
+	}
+
+	value = math.Min(1, value)
+
+	if t.SRGB {
+		value = encodeSRGB(value)
+	}
+
+	return value
+}
+
+// Undo the sRGB transfer function, giving the linear value of an encoded value
+// in the range [0, 1].
+func decodeSRGB(value float64) float64 {
+	if value <= 0.04045 {
+		return value / 12.92
+	}
+
+	return math.Pow((value+0.055)/1.055, 2.4)
+}
+
+// Apply the sRGB transfer function to a linear value in the range [0, 1].
+func encodeSRGB(value float64) float64 {
+	if value <= 0.0031308 {
+		return 12.92 * value
+	}
+
+	return 1.055*math.Pow(value, 1/2.4) - 0.055
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOutputTransform_Apply(t *testing.T) {
+	aces := func(x float64) float64 {
+		return (x * (2.51*x + 0.03)) / (x*(2.43*x+0.59) + 0.14)
+	}
+
+	testCases := []struct {
+		name      string
+		transform OutputTransform
+		color     Color
+		want      Color
+	}{
+		{
+			"default is linear and clamped",
+			MakeOutputTransform(),
+			MakeColor(-0.5, 0.25, 1.5),
+			MakeColor(0, 0.25, 1),
+		},
+		{
+			"exposure",
+			OutputTransform{Exposure: 1, ToneMap: ToneMapClamp},
+			MakeColor(0.1, 0.25, 0.6),
+			MakeColor(0.2, 0.5, 1),
+		},
+		{
+			"negative exposure",
+			OutputTransform{Exposure: -2, ToneMap: ToneMapClamp},
+			MakeColor(2, 1, 0),
+			MakeColor(0.5, 0.25, 0),
+		},
+		{
+			"reinhard",
+			OutputTransform{ToneMap: ToneMapReinhard},
+			MakeColor(1, 3, 0),
+			MakeColor(0.5, 0.75, 0),
+		},
+		{
+			"extended reinhard reaches white at the white point",
+			OutputTransform{ToneMap: ToneMapExtendedReinhard, WhitePoint: 4},
+			MakeColor(4, 1, 8),
+			MakeColor(1, (1+1.0/16)/2, 1),
+		},
+		{
+			"extended reinhard without a white point is plain reinhard",
+			OutputTransform{ToneMap: ToneMapExtendedReinhard},
+			MakeColor(0, 1, 3),
+			MakeColor(0, 0.5, 0.75),
+		},
+		{
+			"extended reinhard with a negative white point is plain reinhard",
+			OutputTransform{ToneMap: ToneMapExtendedReinhard, WhitePoint: -2},
+			MakeColor(0, 1, 3),
+			MakeColor(0, 0.5, 0.75),
+		},
+		{
+			"aces",
+			OutputTransform{ToneMap: ToneMapACES},
+			MakeColor(0.18, 1, 100),
+			MakeColor(aces(0.18), aces(1), 1),
+		},
+		{
+			"srgb",
+			OutputTransform{ToneMap: ToneMapClamp, SRGB: true},
+			MakeColor(0.002, 0.5, 1),
+			MakeColor(0.002*12.92, 0.735357, 1),
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.transform.Apply(tt.color); !tt.want.Equals(got) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// Every tone mapping operator should keep increasing intensities in order and
+// stay within the displayable range.
+func TestOutputTransform_Apply_Monotonic(t *testing.T) {
+	for _, toneMap := range []ToneMap{ToneMapClamp, ToneMapReinhard, ToneMapExtendedReinhard, ToneMapACES} {
+		t.Run(toneMap.String(), func(t *testing.T) {
+			transform := MakeOutputTransform()
+			transform.ToneMap = toneMap
+			transform.SRGB = true
+
+			previous := -1.0
+			for value := 0.0; value < 20; value += 0.05 {
+				got := transform.Apply(MakeColor(value, value, value)).Red()
+				if got < previous || got < 0 || got > 1 {
+					t.Fatalf("Expected non-decreasing values in [0, 1]; got %v after %v for intensity %v", got, previous, value)
+				}
+
+				previous = got
+			}
+		})
+	}
+}
+
+func TestEncodeSRGB(t *testing.T) {
+	// The two pieces of the transfer function should meet.
+	below := encodeSRGB(0.0031308)
+	above := encodeSRGB(math.Nextafter(0.0031308, 1))
+	if math.Abs(above-below) > 1e-6 {
+		t.Errorf("Expected a continuous transfer function; got %v and %v", below, above)
+	}
+
+	if got := encodeSRGB(1); !Float64Equal(1, got) {
+		t.Errorf("Expected full intensity to stay at 1, got %v", got)
+	}
+}
+
+func TestDecodeSRGB(t *testing.T) {
+	for _, value := range []float64{0, 0.002, 0.04045, 0.2, 0.5, 1} {
+		if got := decodeSRGB(encodeSRGB(value)); !Float64Equal(value, got) {
+			t.Errorf("Expected decoding to undo encoding of %v, got %v", value, got)
+		}
+	}
+}
+
+func TestParseToneMap(t *testing.T) {
+	for _, toneMap := range []ToneMap{ToneMapClamp, ToneMapReinhard, ToneMapExtendedReinhard, ToneMapACES} {
+		t.Run(toneMap.String(), func(t *testing.T) {
+			got, err := ParseToneMap(toneMap.String())
+			if err != nil || got != toneMap {
+				t.Errorf("Expected %v, got %v (error %v)", toneMap, got, err)
+			}
+		})
+	}
+
+	if _, err := ParseToneMap("bogus"); err == nil {
+		t.Error("Expected error parsing unknown tone mapping operator")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// A UV mapping flattens the surface of an object onto a texture. Points are
+// given in object space, and are mapped to texture coordinates where u runs
+// from left to right and v runs from bottom to top across the range [0, 1].
+// Mappings may give coordinates outside of that range, which are resolved by
+// the texture's wrap mode.
+type UVMapping interface {
+	Map(point Tuple) (u, v float64)
+}
+
+// A spherical mapping wraps a texture around a sphere centered on the origin,
+// like the equirectangular map of a globe. The center of the texture faces -z,
+// its left and right edges meet on the +z side, and its top and bottom edges
+// are pinched into the poles.
+type SphericalMapping struct{}
+
+// Map a point to the texture by its longitude and latitude.
+func (SphericalMapping) Map(point Tuple) (float64, float64) {
+	radius := math.Sqrt(point.X*point.X + point.Y*point.Y + point.Z*point.Z)
+	if radius == 0 {
+		return 0.5, 0.5
+	}
+
+	polar := math.Acos(math.Max(-1, math.Min(1, point.Y/radius)))
+
+	return azimuthalU(point), 1 - polar/math.Pi
+}
+
+// A planar mapping projects a texture straight down onto the xz plane, with
+// one copy of the texture covering each unit square.
+type PlanarMapping struct{}
+
+// Map a point to the texture by its x and z coordinates.
+func (PlanarMapping) Map(point Tuple) (float64, float64) {
+	return point.X, point.Z
+}
+
+// A cylindrical mapping wraps a texture around the y axis, with one copy of
+// the texture covering each unit of height. Like the spherical mapping, the
+// center of the texture faces -z and its edges meet on the +z side.
+type CylindricalMapping struct{}
+
+// Map a point to the texture by its angle around the y axis and its height.
+func (CylindricalMapping) Map(point Tuple) (float64, float64) {
+	return azimuthalU(point), point.Y
+}
+
+// A cube mapping projects each face of a cube centered on the origin onto a
+// square of a texture laid out as an unfolded cube, in the shape of a cross
+// four squares wide and three squares tall:
+//
+//	     +y
+//	-x   -z   +x   +z
+//	     -y
+//
+// Each face appears as it does when looking at it from outside of the cube,
+// with the -z face facing forward and +y up. Points are projected onto the face
+// that they are closest to.
+type CubeMapping struct{}
+
+// Map a point to the texture by projecting it onto the nearest face of the
+// cube.
+func (CubeMapping) Map(point Tuple) (float64, float64) {
+	x, y, z := point.X, point.Y, point.Z
+	absX, absY, absZ := math.Abs(x), math.Abs(y), math.Abs(z)
+
+	// The column and row of the face's square, counting rows from the
+	// bottom, and the coordinates of the point on the face in the range
+	// [-1, 1].
+	var column, row int
+	var faceU, faceV float64
+	switch {
+	case absX >= absY && absX >= absZ && x > 0:
+		column, row, faceU, faceV = 2, 1, z/absX, y/absX
+	case absX >= absY && absX >= absZ:
+		column, row, faceU, faceV = 0, 1, -z/absX, y/absX
+	case absY >= absZ && y > 0:
+		column, row, faceU, faceV = 1, 2, x/absY, z/absY
+	case absY >= absZ:
+		column, row, faceU, faceV = 1, 0, x/absY, -z/absY
+	case z > 0:
+		column, row, faceU, faceV = 3, 1, -x/absZ, y/absZ
+	case z < 0:
+		column, row, faceU, faceV = 1, 1, x/absZ, y/absZ
+	default:
+		// The origin isn't on any face.
+		return 0.375, 0.5
+	}
+
+	return (float64(column) + (faceU+1)/2) / 4, (float64(row) + (faceV+1)/2) / 3
+}
+
+// Get the u coordinate of a point from its angle around the y axis. The
+// coordinate is one half in the -z direction and increases towards +x, so
+// textures aren't mirrored when seen from outside of an object.
+func azimuthalU(point Tuple) float64 {
+	return math.Atan2(point.X, -point.Z)/(2*math.Pi) + 0.5
+}
+
+// Parse the name of a UV mapping as it would be given on the command line.
+func ParseUVMapping(name string) (UVMapping, error) {
+	switch name {
+	case "spherical":
+		return SphericalMapping{}, nil
+	case "planar":
+		return PlanarMapping{}, nil
+	case "cylindrical":
+		return CylindricalMapping{}, nil
+	case "cube":
+		return CubeMapping{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown UV mapping '%s'", name)
+}
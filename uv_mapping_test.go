@@ -0,0 +1,99 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUVMapping_Map(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mapping UVMapping
+		point   Tuple
+		wantU   float64
+		wantV   float64
+	}{
+		{"spherical front", SphericalMapping{}, MakePoint(0, 0, -1), 0.5, 0.5},
+		{"spherical right", SphericalMapping{}, MakePoint(1, 0, 0), 0.75, 0.5},
+		{"spherical left", SphericalMapping{}, MakePoint(-1, 0, 0), 0.25, 0.5},
+		{"spherical upper front", SphericalMapping{}, MakePoint(0, math.Sqrt2/2, -math.Sqrt2/2), 0.5, 0.75},
+		{"spherical lower front", SphericalMapping{}, MakePoint(0, -math.Sqrt2/2, -math.Sqrt2/2), 0.5, 0.25},
+		{"spherical scaled", SphericalMapping{}, MakePoint(2, 0, 0), 0.75, 0.5},
+		{"planar", PlanarMapping{}, MakePoint(0.25, 0.5, -1.75), 0.25, -1.75},
+		{"cylindrical front", CylindricalMapping{}, MakePoint(0, 0.25, -1), 0.5, 0.25},
+		{"cylindrical right", CylindricalMapping{}, MakePoint(1, 1.5, 0), 0.75, 1.5},
+		{"cube front center", CubeMapping{}, MakePoint(0, 0, -1), 0.375, 0.5},
+		{"cube front bottom left", CubeMapping{}, MakePoint(-0.9, -0.9, -1), 0.2625, 0.35},
+		{"cube right center", CubeMapping{}, MakePoint(1, 0, 0), 0.625, 0.5},
+		{"cube back center", CubeMapping{}, MakePoint(0, 0, 1), 0.875, 0.5},
+		{"cube left center", CubeMapping{}, MakePoint(-1, 0, 0), 0.125, 0.5},
+		{"cube top center", CubeMapping{}, MakePoint(0, 1, 0), 0.375, 5.0 / 6},
+		{"cube bottom center", CubeMapping{}, MakePoint(0, -1, 0), 0.375, 1.0 / 6},
+		{"cube scaled", CubeMapping{}, MakePoint(0, 0, -2), 0.375, 0.5},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			u, v := tt.mapping.Map(tt.point)
+			if !Float64Equal(tt.wantU, u) || !Float64Equal(tt.wantV, v) {
+				t.Errorf("Expected (%v, %v), got (%v, %v)", tt.wantU, tt.wantV, u, v)
+			}
+		})
+	}
+}
+
+// Neighboring faces of the cube meet at the edges of their squares in the
+// unfolded cube, where the left and right edges of the texture count as
+// meeting.
+func TestCubeMapping_Map_Edges(t *testing.T) {
+	const nearEdge = 0.999999
+	testCases := []struct {
+		name   string
+		pointA Tuple
+		pointB Tuple
+	}{
+		{"front and right", MakePoint(nearEdge, 0.5, -1), MakePoint(1, 0.5, -nearEdge)},
+		{"right and back", MakePoint(1, 0.5, nearEdge), MakePoint(nearEdge, 0.5, 1)},
+		{"back and left", MakePoint(-nearEdge, 0.5, 1), MakePoint(-1, 0.5, nearEdge)},
+		{"left and front", MakePoint(-1, 0.5, -nearEdge), MakePoint(-nearEdge, 0.5, -1)},
+		{"front and top", MakePoint(0.5, nearEdge, -1), MakePoint(0.5, 1, -nearEdge)},
+		{"front and bottom", MakePoint(0.5, -nearEdge, -1), MakePoint(0.5, -1, -nearEdge)},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			uA, vA := CubeMapping{}.Map(tt.pointA)
+			uB, vB := CubeMapping{}.Map(tt.pointB)
+
+			deltaU := math.Abs(uA - uB)
+			deltaU = math.Min(deltaU, 1-deltaU)
+			if deltaU > 1e-5 || math.Abs(vA-vB) > 1e-5 {
+				t.Errorf("Expected (%v, %v) to meet (%v, %v)", uA, vA, uB, vB)
+			}
+		})
+	}
+}
+
+func TestParseUVMapping(t *testing.T) {
+	testCases := []struct {
+		name    string
+		want    UVMapping
+		wantErr bool
+	}{
+		{"spherical", SphericalMapping{}, false},
+		{"planar", PlanarMapping{}, false},
+		{"cylindrical", CylindricalMapping{}, false},
+		{"cube", CubeMapping{}, false},
+		{"bogus", nil, true},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseUVMapping(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error = %v, got %v", tt.wantErr, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Expected mapping %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
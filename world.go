@@ -4,9 +4,16 @@ package main
 type World struct {
 	// The light source used to illuminate the world.
 	Light PointLight
+	// A directional light, like the sun, that illuminates the world along
+	// with the light source. It is off unless it is given an intensity.
+	Sun DirectionalLight
 
 	// The renderable objects in the world.
 	Objects []Object
+
+	// The environment seen by rays that miss every object. A world without an
+	// environment is surrounded by black.
+	Environment Environment
 }
 
 // Create an empty world.
@@ -38,24 +45,51 @@ func MakeDefaultWorld() World {
 }
 
 // Compute the color resulting from the given ray intersecting the objects in
-// the world.
+// the world, shaded with the Phong model. Emissive objects add the light they
+// give off.
 func (w World) ColorAt(ray Ray) Color {
-	intersections := w.intersect(ray)
-	intersection, hit := intersections.Hit()
+	return WhittedIntegrator{}.Radiance(ray, w, 0, nil)
+}
 
-	// No hit means we should return the color of the void.
+// Get the computations for the nearest hit along a ray. The boolean return
+// value is false if the ray missed every object, in which case the
+// computations should be ignored.
+func (w World) primaryHit(ray Ray) (IntersectionComputation, bool) {
+	intersection, hit := w.intersect(ray).Hit()
 	if !hit {
+		return IntersectionComputation{}, false
+	}
+
+	return intersection.PrepareComputations(ray), true
+}
+
+// Get the color of the environment in the direction of a ray.
+func (w World) environmentColor(ray Ray) Color {
+	if w.Environment == nil {
 		return MakeColor(0, 0, 0)
 	}
 
-	intersectionComps := intersection.PrepareComputations(ray)
+	return w.Environment.ColorInDirection(ray.Direction)
+}
+
+// Determine if anything blocks the straight line between two points at a
+// moment in time. Points on surfaces should be nudged off of the surface first.
+func (w World) isOccluded(from, to Tuple, time float64) bool {
+	toTarget := to.Subtract(from)
+	distance := toTarget.Magnitude()
+	ray := MakeRayAtTime(from, toTarget.Normalized(), time)
 
-	return w.shadeHit(intersectionComps)
+	hit, ok := w.intersect(ray).Hit()
+
+	return ok && hit.T < distance
 }
 
 func (w World) intersect(ray Ray) (intersections Intersections) {
-	for _, object := range w.Objects {
-		intersections = append(intersections, object.Intersect(ray)...)
+	for index, object := range w.Objects {
+		for _, intersection := range object.Intersect(ray) {
+			intersection.ObjectIndex = index
+			intersections = append(intersections, intersection)
+		}
 	}
 
 	if intersections != nil {
@@ -68,11 +102,27 @@ func (w World) intersect(ray Ray) (intersections Intersections) {
 // Find the color that should be produced at the location of the given
 // intersection.
 func (w World) shadeHit(computation IntersectionComputation) Color {
-	return Lighting(
-		computation.Object.Material(),
+	return w.shadeHitWithAmbientOcclusion(computation, 1)
+}
+
+// Find the color that should be produced at the location of the given
+// intersection with the ambient light scaled by the fraction of ambient light
+// that reaches it.
+func (w World) shadeHitWithAmbientOcclusion(computation IntersectionComputation, visibility float64) Color {
+	material := computation.material()
+	color := LightingWithAmbientOcclusion(
+		material,
 		w.Light,
 		computation.Point,
 		computation.EyeVector,
 		computation.NormalVector,
+		visibility,
 	)
+
+	if w.Sun.IsOn() {
+		color = color.Add(reflectedLight(material, w.Sun.Intensity, w.Sun.Direction, computation.EyeVector, computation.NormalVector))
+	}
+
+	// Emissive objects glow whether or not they are lit.
+	return color.Add(material.emitted())
 }
@@ -55,12 +55,35 @@ func TestWorld_ColorAt(t *testing.T) {
 			MakeRay(MakePoint(0, 0, -5), MakeVector(0, 1, 0)),
 			MakeColor(0, 0, 0),
 		},
+		{
+			"ray misses with an environment",
+			func() World {
+				world := MakeDefaultWorld()
+				world.Environment = MakeConstantEnvironment(MakeColor(0.2, 0.4, 0.8))
+				return world
+			}(),
+			MakeRay(MakePoint(0, 0, -5), MakeVector(0, 1, 0)),
+			MakeColor(0.2, 0.4, 0.8),
+		},
 		{
 			"ray hit in default world",
 			MakeDefaultWorld(),
 			MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1)),
 			MakeColor(0.38066, 0.47583, 0.2855),
 		},
+		{
+			"ray hits an emissive object",
+			func() World {
+				world := MakeDefaultWorld()
+				outer := world.Objects[0].(Sphere)
+				outer.material.Emission = MakeColor(0.5, 0.25, 0)
+				outer.material.EmissionStrength = 2
+				world.Objects[0] = outer
+				return world
+			}(),
+			MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1)),
+			MakeColor(1.38066, 0.97583, 0.2855),
+		},
 		{
 			"intersection behind ray",
 			func() World {
@@ -92,6 +115,7 @@ func TestWorld_Intersect(t *testing.T) {
 	world := MakeDefaultWorld()
 	ray := MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1))
 	wantIntersections := []float64{4, 4.5, 5.5, 6}
+	wantIndices := []int{0, 1, 1, 0}
 
 	intersections := world.intersect(ray)
 	if got := len(intersections); got != len(wantIntersections) {
@@ -102,6 +126,10 @@ func TestWorld_Intersect(t *testing.T) {
 		if got := intersection.T; !Float64Equal(wantIntersections[i], got) {
 			t.Errorf("Expected intersection %d to have t-value %f; got %f", i, wantIntersections[i], got)
 		}
+
+		if got := intersection.ObjectIndex; got != wantIndices[i] {
+			t.Errorf("Expected intersection %d to have object index %d; got %d", i, wantIndices[i], got)
+		}
 	}
 }
 
@@ -143,6 +171,28 @@ func TestWorld_ShadeHit(t *testing.T) {
 	}
 }
 
+// A directional light lights surfaces the same way as a point light in the
+// same direction.
+func TestWorld_ShadeHit_Sun(t *testing.T) {
+	ray := MakeRay(MakePoint(0, 0, -5), MakeVector(0, 0, 1))
+
+	pointLit := MakeDefaultWorld()
+	pointLit.Light = MakePointLight(MakePoint(-10, 10, -10), MakeColor(1, 1, 1))
+	want := pointLit.shadeHit(MakeIntersection(4, pointLit.Objects[0]).PrepareComputations(ray))
+
+	sunLit := MakeDefaultWorld()
+	sunLit.Light = MakePointLight(MakePoint(-10, 10, -10), MakeColor(1, 1, 1))
+	sunLit.Light.Intensity = MakeColor(0, 0, 0)
+	// The point light adds ambient light of its own color.
+	ambient := sunLit.Objects[0].Material().Color.Multiply(sunLit.Objects[0].Material().Ambient)
+	sunLit.Sun = MakeDirectionalLight(MakePoint(-10, 10, -10).Subtract(MakePoint(0, 0, -1)), MakeColor(1, 1, 1))
+	got := sunLit.shadeHit(MakeIntersection(4, sunLit.Objects[0]).PrepareComputations(ray)).Add(ambient)
+
+	if !want.Equals(got) {
+		t.Errorf("Expected color %v, got %v", want, got)
+	}
+}
+
 func assertContainsObject(t *testing.T, objects []Object, want Object) {
 	for _, obj := range objects {
 		if objectsEqual(obj, want) {